@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -12,16 +13,36 @@ type Config struct {
 	RedisUri string `mapstructure:"REDIS_URL"`
 	Port     string `mapstructure:"PORT"`
 
+	AccessTokenAlgorithm  string        `mapstructure:"ACCESS_TOKEN_ALGORITHM"`
 	AccessTokenPrivateKey string        `mapstructure:"ACCESS_TOKEN_PRIVATE_KEY"`
 	AccessTokenPublicKey  string        `mapstructure:"ACCESS_TOKEN_PUBLIC_KEY"`
 	AccessTokenExpiresIn  time.Duration `mapstructure:"ACCESS_TOKEN_EXPIRES_IN"`
 	AccessTokenMaxAge     int           `mapstructure:"ACCESS_TOKEN_MAXAGE"`
 
+	RefreshTokenAlgorithm  string        `mapstructure:"REFRESH_TOKEN_ALGORITHM"`
 	RefreshTokenPrivateKey string        `mapstructure:"REFRESH_TOKEN_PRIVATE_KEY"`
 	RefreshTokenPublicKey  string        `mapstructure:"REFRESH_TOKEN_PUBLIC_KEY"`
 	RefreshTokenExpiresIn  time.Duration `mapstructure:"REFRESH_TOKEN_EXPIRES_IN"`
 	RefreshTokenMaxAge     int           `mapstructure:"REFRESH_TOKEN_MAXAGE"`
 
+	TokenIssuer          string `mapstructure:"TOKEN_ISSUER"`
+	AccessTokenAudience  string `mapstructure:"ACCESS_TOKEN_AUDIENCE"`
+	RefreshTokenAudience string `mapstructure:"REFRESH_TOKEN_AUDIENCE"`
+
+	//Set Before First Start, Changing It Invalidates Outstanding One-Time Tokens
+	TokenPepper string `mapstructure:"TOKEN_PEPPER"`
+
+	TokenKeyRotationInterval time.Duration `mapstructure:"TOKEN_KEY_ROTATION_INTERVAL"`
+	TokenKeySyncInterval     time.Duration `mapstructure:"TOKEN_KEY_SYNC_INTERVAL"`
+	TokenKeyPublishLead      time.Duration `mapstructure:"TOKEN_KEY_PUBLISH_LEAD"`
+	JWKSMaxAge               time.Duration `mapstructure:"JWKS_MAX_AGE"`
+
+	//Base64 of 32 Random Bytes, Encrypts Private Keys in the Shared Key Store
+	TokenKeyEncryptionKey string `mapstructure:"TOKEN_KEY_ENCRYPTION_KEY"`
+
+	AccessTokenKeys  *KeyRing `mapstructure:"-"`
+	RefreshTokenKeys *KeyRing `mapstructure:"-"`
+
 	Origin string `mapstructure:"CLIENT_ORIGIN"`
 
 	EmailFrom string `mapstructure:"EMAIL_FROM"`
@@ -30,6 +51,80 @@ type Config struct {
 	SMTPPort  int    `mapstructure:"SMTP_PORT"`
 	SMTPUser  string `mapstructure:"SMTP_USER"`
 
+	RateLimitLogin          string `mapstructure:"RATE_LIMIT_LOGIN"`
+	RateLimitRegister       string `mapstructure:"RATE_LIMIT_REGISTER"`
+	RateLimitForgotPassword string `mapstructure:"RATE_LIMIT_FORGOT_PASSWORD"`
+	RateLimitVerifyEmail    string `mapstructure:"RATE_LIMIT_VERIFY_EMAIL"`
+	RateLimitUnlockAccount  string `mapstructure:"RATE_LIMIT_UNLOCK_ACCOUNT"`
+
+	PasswordResetExpiresIn time.Duration `mapstructure:"PASSWORD_RESET_EXPIRES_IN"`
+
+	VerificationCodeExpiresIn   time.Duration `mapstructure:"VERIFICATION_CODE_EXPIRES_IN"`
+	RateLimitResendVerification string        `mapstructure:"RATE_LIMIT_RESEND_VERIFICATION"`
+
+	LoginMaxAttempts     int           `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockoutDuration time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+
+	TOTPIssuer            string        `mapstructure:"TOTP_ISSUER"`
+	MFAChallengeExpiresIn time.Duration `mapstructure:"MFA_CHALLENGE_EXPIRES_IN"`
+	RateLimitMFA          string        `mapstructure:"RATE_LIMIT_MFA"`
+
+	WebAuthnRPID          string   `mapstructure:"WEBAUTHN_RP_ID"`
+	WebAuthnRPDisplayName string   `mapstructure:"WEBAUTHN_RP_DISPLAY_NAME"`
+	WebAuthnRPOrigins     []string `mapstructure:"WEBAUTHN_RP_ORIGINS"`
+
+	OAuthRedirectURL    string        `mapstructure:"OAUTH_REDIRECT_URL"`
+	OAuthStateExpiresIn time.Duration `mapstructure:"OAUTH_STATE_EXPIRES_IN"`
+
+	GoogleClientID     string `mapstructure:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `mapstructure:"GOOGLE_CLIENT_SECRET"`
+	GoogleIssuerURL    string `mapstructure:"GOOGLE_ISSUER_URL"`
+
+	GitHubClientID     string `mapstructure:"GITHUB_CLIENT_ID"`
+	GitHubClientSecret string `mapstructure:"GITHUB_CLIENT_SECRET"`
+	GitHubAuthURL      string `mapstructure:"GITHUB_AUTH_URL"`
+	GitHubTokenURL     string `mapstructure:"GITHUB_TOKEN_URL"`
+	GitHubAPIURL       string `mapstructure:"GITHUB_API_URL"`
+
+	OIDCProviderName string `mapstructure:"OIDC_PROVIDER_NAME"`
+	OIDCClientID     string `mapstructure:"OIDC_CLIENT_ID"`
+	OIDCClientSecret string `mapstructure:"OIDC_CLIENT_SECRET"`
+	OIDCIssuerURL    string `mapstructure:"OIDC_ISSUER_URL"`
+
+	MagicLinkExpiresIn time.Duration `mapstructure:"MAGIC_LINK_EXPIRES_IN"`
+	RateLimitMagicLink string        `mapstructure:"RATE_LIMIT_MAGIC_LINK"`
+
+	AdminEmails []string `mapstructure:"ADMIN_EMAILS"`
+
+	AccountDeletionGracePeriod time.Duration `mapstructure:"ACCOUNT_DELETION_GRACE_PERIOD"`
+	AccountPurgeInterval       time.Duration `mapstructure:"ACCOUNT_PURGE_INTERVAL"`
+	RateLimitRestoreAccount    string        `mapstructure:"RATE_LIMIT_RESTORE_ACCOUNT"`
+
+	RateLimitChangePassword string `mapstructure:"RATE_LIMIT_CHANGE_PASSWORD"`
+
+	PasswordHashAlgorithm string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	Argon2Memory          uint32 `mapstructure:"ARGON2_MEMORY"`
+	Argon2Iterations      uint32 `mapstructure:"ARGON2_ITERATIONS"`
+	Argon2Parallelism     uint8  `mapstructure:"ARGON2_PARALLELISM"`
+	Argon2SaltLength      uint32 `mapstructure:"ARGON2_SALT_LENGTH"`
+	Argon2KeyLength       uint32 `mapstructure:"ARGON2_KEY_LENGTH"`
+	BcryptCost            int    `mapstructure:"BCRYPT_COST"`
+
+	PasswordMinLength            int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMaxLength            int    `mapstructure:"PASSWORD_MAX_LENGTH"`
+	PasswordRequireUppercase     bool   `mapstructure:"PASSWORD_REQUIRE_UPPERCASE"`
+	PasswordRequireLowercase     bool   `mapstructure:"PASSWORD_REQUIRE_LOWERCASE"`
+	PasswordRequireDigit         bool   `mapstructure:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSymbol        bool   `mapstructure:"PASSWORD_REQUIRE_SYMBOL"`
+	PasswordDisallowPersonalInfo bool   `mapstructure:"PASSWORD_DISALLOW_PERSONAL_INFO"`
+	PasswordBreachedList         string `mapstructure:"PASSWORD_BREACHED_LIST"`
+
+	EmailChangeExpiresIn time.Duration `mapstructure:"EMAIL_CHANGE_EXPIRES_IN"`
+	RateLimitEmailChange string        `mapstructure:"RATE_LIMIT_EMAIL_CHANGE"`
+
+	DataExportExpiresIn time.Duration `mapstructure:"DATA_EXPORT_EXPIRES_IN"`
+	RateLimitDataExport string        `mapstructure:"RATE_LIMIT_DATA_EXPORT"`
+
 	Env string `mapstructure:"ENV"`
 }
 
@@ -43,12 +138,93 @@ func initConfig(path string) (config *Config, err error) {
 
 	viper.AutomaticEnv()
 
+	//Defaults
+	viper.SetDefault("ACCESS_TOKEN_ALGORITHM", AlgorithmRS256)
+	viper.SetDefault("REFRESH_TOKEN_ALGORITHM", AlgorithmRS256)
+	viper.SetDefault("TOKEN_ISSUER", "gipitty")
+	viper.SetDefault("TOKEN_PEPPER", "")
+	viper.SetDefault("ACCESS_TOKEN_AUDIENCE", "gipitty-api")
+	viper.SetDefault("REFRESH_TOKEN_AUDIENCE", "gipitty-refresh")
+	viper.SetDefault("TOKEN_KEY_ROTATION_INTERVAL", "720h")
+	viper.SetDefault("TOKEN_KEY_SYNC_INTERVAL", "1m")
+	viper.SetDefault("TOKEN_KEY_PUBLISH_LEAD", "10m")
+	viper.SetDefault("JWKS_MAX_AGE", "5m")
+	viper.SetDefault("TOKEN_KEY_ENCRYPTION_KEY", "")
+	viper.SetDefault("RATE_LIMIT_LOGIN", "10/15m")
+	viper.SetDefault("RATE_LIMIT_REGISTER", "5/1h")
+	viper.SetDefault("RATE_LIMIT_FORGOT_PASSWORD", "3/15m")
+	viper.SetDefault("RATE_LIMIT_VERIFY_EMAIL", "10/15m")
+	viper.SetDefault("RATE_LIMIT_UNLOCK_ACCOUNT", "10/15m")
+	viper.SetDefault("PASSWORD_RESET_EXPIRES_IN", "10m")
+	viper.SetDefault("VERIFICATION_CODE_EXPIRES_IN", "24h")
+	viper.SetDefault("RATE_LIMIT_RESEND_VERIFICATION", "3/1h")
+	viper.SetDefault("LOGIN_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("TOTP_ISSUER", "Gipitty")
+	viper.SetDefault("MFA_CHALLENGE_EXPIRES_IN", "5m")
+	viper.SetDefault("RATE_LIMIT_MFA", "10/15m")
+	viper.SetDefault("WEBAUTHN_RP_ID", "localhost")
+	viper.SetDefault("WEBAUTHN_RP_DISPLAY_NAME", "Gipitty")
+	viper.SetDefault("WEBAUTHN_RP_ORIGINS", "http://localhost:3000")
+	viper.SetDefault("OAUTH_REDIRECT_URL", "http://localhost:8000/api/auth/oauth")
+	viper.SetDefault("OAUTH_STATE_EXPIRES_IN", "10m")
+	viper.SetDefault("GOOGLE_ISSUER_URL", "https://accounts.google.com")
+	viper.SetDefault("GITHUB_AUTH_URL", "https://github.com/login/oauth/authorize")
+	viper.SetDefault("GITHUB_TOKEN_URL", "https://github.com/login/oauth/access_token")
+	viper.SetDefault("GITHUB_API_URL", "https://api.github.com")
+	viper.SetDefault("OIDC_PROVIDER_NAME", "oidc")
+	viper.SetDefault("MAGIC_LINK_EXPIRES_IN", "10m")
+	viper.SetDefault("RATE_LIMIT_MAGIC_LINK", "3/15m")
+	viper.SetDefault("ACCOUNT_DELETION_GRACE_PERIOD", "720h")
+	viper.SetDefault("ACCOUNT_PURGE_INTERVAL", "1h")
+	viper.SetDefault("RATE_LIMIT_RESTORE_ACCOUNT", "5/15m")
+	viper.SetDefault("RATE_LIMIT_CHANGE_PASSWORD", "5/15m")
+	viper.SetDefault("PASSWORD_HASH_ALGORITHM", "argon2id")
+	viper.SetDefault("ARGON2_MEMORY", 64*1024)
+	viper.SetDefault("ARGON2_ITERATIONS", 3)
+	viper.SetDefault("ARGON2_PARALLELISM", 2)
+	viper.SetDefault("ARGON2_SALT_LENGTH", 16)
+	viper.SetDefault("ARGON2_KEY_LENGTH", 32)
+	viper.SetDefault("BCRYPT_COST", 10)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_MAX_LENGTH", 72)
+	viper.SetDefault("PASSWORD_REQUIRE_UPPERCASE", false)
+	viper.SetDefault("PASSWORD_REQUIRE_LOWERCASE", false)
+	viper.SetDefault("PASSWORD_REQUIRE_DIGIT", false)
+	viper.SetDefault("PASSWORD_REQUIRE_SYMBOL", false)
+	viper.SetDefault("PASSWORD_DISALLOW_PERSONAL_INFO", true)
+	viper.SetDefault("PASSWORD_BREACHED_LIST", "")
+	viper.SetDefault("EMAIL_CHANGE_EXPIRES_IN", "1h")
+	viper.SetDefault("RATE_LIMIT_EMAIL_CHANGE", "3/1h")
+	viper.SetDefault("DATA_EXPORT_EXPIRES_IN", "24h")
+	viper.SetDefault("RATE_LIMIT_DATA_EXPORT", "2/24h")
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
 	}
 
 	err = viper.Unmarshal(&config)
+	if err != nil {
+		return
+	}
+
+	//Every Instance and Every Cached JWKS Must Have a Key Before It Signs
+	if config.TokenKeyPublishLead < config.TokenKeySyncInterval+config.JWKSMaxAge {
+		err = fmt.Errorf("TOKEN_KEY_PUBLISH_LEAD %s must be at least TOKEN_KEY_SYNC_INTERVAL plus JWKS_MAX_AGE (%s)", config.TokenKeyPublishLead, config.TokenKeySyncInterval+config.JWKSMaxAge)
+		return
+	}
+	if _, err = newKeyEncryptionAEAD(config.TokenKeyEncryptionKey); err != nil {
+		err = fmt.Errorf("TOKEN_KEY_ENCRYPTION_KEY: %w", err)
+		return
+	}
+
+	//Key Rings
+	config.AccessTokenKeys, err = NewKeyRing(config.AccessTokenAlgorithm, config.AccessTokenPrivateKey, config.AccessTokenPublicKey)
+	if err != nil {
+		return
+	}
+	config.RefreshTokenKeys, err = NewKeyRing(config.RefreshTokenAlgorithm, config.RefreshTokenPrivateKey, config.RefreshTokenPublicKey)
 	return
 }
 
@@ -0,0 +1,382 @@
+package config
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+const signingKeyBits = 2048
+
+// Length of the AES-256 key that encrypts private keys in the shared key store.
+const keyEncryptionKeyLength = 32
+
+// Signing algorithms operators may choose per token type. Validation only
+// accepts these, and only the algorithm of the key a token names.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var signingAlgorithms = map[string]bool{
+	AlgorithmRS256: true,
+	AlgorithmES256: true,
+	AlgorithmEdDSA: true,
+}
+
+// A token signing key. Keys without a private half can only verify.
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	PrivateKey  crypto.Signer
+	PublicKey   crypto.PublicKey
+	ActivatesAt time.Time
+	ExpiresAt   time.Time
+}
+
+// Holds every key a token type may currently be verified with and picks the
+// one new tokens are signed with. Safe for concurrent use.
+type KeyRing struct {
+	mu        sync.RWMutex
+	algorithm string
+	keys      []*SigningKey
+}
+
+// Builds a ring for the configured algorithm holding the base64 encoded PEM
+// key pair from the environment.
+func NewKeyRing(algorithm string, privateKey string, publicKey string) (*KeyRing, error) {
+	if !signingAlgorithms[algorithm] {
+		return nil, fmt.Errorf("keyring: unsupported algorithm %q", algorithm)
+	}
+
+	ring := &KeyRing{algorithm: algorithm}
+	if privateKey == "" {
+		return ring, nil
+	}
+
+	key, err := ParseSigningKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if key.Algorithm != algorithm {
+		return nil, fmt.Errorf("keyring: %s key configured for %s", key.Algorithm, algorithm)
+	}
+
+	if publicKey != "" {
+		decodedPublicKey, err := base64.StdEncoding.DecodeString(publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: decode public key: %w", err)
+		}
+		public, err := parsePublicKeyPEM(decodedPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if !public.(interface{ Equal(crypto.PublicKey) bool }).Equal(key.PublicKey) {
+			return nil, errors.New("keyring: public key does not match private key")
+		}
+	}
+
+	ring.keys = []*SigningKey{key}
+	return ring, nil
+}
+
+// Parses a base64 encoded PEM private key into a signing key identified by
+// its RFC 7638 thumbprint, so every instance derives the same kid.
+func ParseSigningKey(privateKey string) (*SigningKey, error) {
+	decodedPrivateKey, err := base64.StdEncoding.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decode private key: %w", err)
+	}
+
+	block, _ := pem.Decode(decodedPrivateKey)
+	if block == nil {
+		return nil, errors.New("keyring: private key is not PEM encoded")
+	}
+
+	var parsed interface{}
+	parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keyring: parse private key: %w", err)
+	}
+
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("keyring: unsupported private key type")
+	}
+
+	return newSigningKey(signer)
+}
+
+func GenerateSigningKey(algorithm string) (*SigningKey, error) {
+	var signer crypto.Signer
+	var err error
+
+	switch algorithm {
+	case AlgorithmRS256:
+		signer, err = rsa.GenerateKey(rand.Reader, signingKeyBits)
+	case AlgorithmES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case AlgorithmEdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("keyring: unsupported algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keyring: generate key: %w", err)
+	}
+
+	return newSigningKey(signer)
+}
+
+// Encodes the private half of a key as base64 PEM, the format ParseSigningKey reads.
+func EncodeSigningKey(key *SigningKey) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("keyring: encode private key: %w", err)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	return base64.StdEncoding.EncodeToString(encoded), nil
+}
+
+// Encrypts the private half of a key with AES-256-GCM under the base64
+// encoded encryption key, so the key store alone cannot forge tokens.
+func SealSigningKey(key *SigningKey, encryptionKey string) (string, error) {
+	encoded, err := EncodeSigningKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newKeyEncryptionAEAD(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("keyring: generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(encoded), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypts a key sealed by SealSigningKey.
+func OpenSigningKey(sealed string, encryptionKey string) (*SigningKey, error) {
+	aead, err := newKeyEncryptionAEAD(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decode sealed key: %w", err)
+	}
+	if len(decoded) < aead.NonceSize() {
+		return nil, errors.New("keyring: sealed key is truncated")
+	}
+
+	encoded, err := aead.Open(nil, decoded[:aead.NonceSize()], decoded[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decrypt private key: %w", err)
+	}
+
+	return ParseSigningKey(string(encoded))
+}
+
+func newKeyEncryptionAEAD(encryptionKey string) (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: decode encryption key: %w", err)
+	}
+	if len(key) != keyEncryptionKeyLength {
+		return nil, fmt.Errorf("keyring: encryption key must be %d bytes, got %d", keyEncryptionKeyLength, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func newSigningKey(signer crypto.Signer) (*SigningKey, error) {
+	algorithm, err := algorithmFor(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{
+		Algorithm:  algorithm,
+		PrivateKey: signer,
+		PublicKey:  signer.Public(),
+	}
+
+	jwk := key.JWK()
+	thumbprint, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("keyring: thumbprint: %w", err)
+	}
+	key.ID = base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	return key, nil
+}
+
+// Each key type maps to exactly one algorithm, so a key can never be used
+// with an algorithm it was not generated for.
+func algorithmFor(signer crypto.Signer) (string, error) {
+	switch key := signer.(type) {
+	case *rsa.PrivateKey:
+		return AlgorithmRS256, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", errors.New("keyring: ES256 requires a P-256 key")
+		}
+		return AlgorithmES256, nil
+	case ed25519.PrivateKey:
+		return AlgorithmEdDSA, nil
+	}
+	return "", errors.New("keyring: unsupported private key type")
+}
+
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("keyring: public key is not PEM encoded")
+	}
+
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keyring: parse public key: %w", err)
+	}
+
+	return public, nil
+}
+
+func (key *SigningKey) JWK() jose.JSONWebKey {
+	return jose.JSONWebKey{Key: key.PublicKey, KeyID: key.ID, Algorithm: key.Algorithm, Use: "sig"}
+}
+
+func (key *SigningKey) expired(now time.Time) bool {
+	return !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(now)
+}
+
+// The algorithm new keys for this ring are generated with.
+func (ring *KeyRing) Algorithm() string {
+	return ring.algorithm
+}
+
+// Returns the algorithms of the ring's unexpired keys, the only ones its
+// tokens may be validated with.
+func (ring *KeyRing) Algorithms() []string {
+	seen := map[string]bool{}
+	algorithms := []string{}
+	for _, key := range ring.Keys() {
+		if signingAlgorithms[key.Algorithm] && !seen[key.Algorithm] {
+			seen[key.Algorithm] = true
+			algorithms = append(algorithms, key.Algorithm)
+		}
+	}
+	return algorithms
+}
+
+// Returns the most recently activated key. Before any key has activated,
+// as on a first deploy, the earliest scheduled key is used instead.
+func (ring *KeyRing) SigningKey() (*SigningKey, error) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	now := time.Now()
+	var active, earliest *SigningKey
+	for _, key := range ring.keys {
+		if key.PrivateKey == nil || key.expired(now) {
+			continue
+		}
+		if !key.ActivatesAt.After(now) && (active == nil || key.ActivatesAt.After(active.ActivatesAt)) {
+			active = key
+		}
+		if earliest == nil || key.ActivatesAt.Before(earliest.ActivatesAt) {
+			earliest = key
+		}
+	}
+
+	if active != nil {
+		return active, nil
+	}
+	if earliest != nil {
+		return earliest, nil
+	}
+	return nil, errors.New("keyring: no signing key available")
+}
+
+// Finds an unexpired key by kid, including keys published ahead of activation.
+func (ring *KeyRing) Lookup(kid string) (*SigningKey, bool) {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	now := time.Now()
+	for _, key := range ring.keys {
+		if key.ID == kid && !key.expired(now) {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Returns every unexpired key, newest first.
+func (ring *KeyRing) Keys() []*SigningKey {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	now := time.Now()
+	keys := []*SigningKey{}
+	for _, key := range ring.keys {
+		if !key.expired(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActivatesAt.After(keys[j].ActivatesAt) })
+
+	return keys
+}
+
+// Swaps in the keys loaded from the shared key store. An empty set is
+// ignored so the ring keeps its environment key until the store is seeded.
+func (ring *KeyRing) Replace(keys []*SigningKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+	ring.keys = keys
+}
+
+func (ring *KeyRing) JWKS() jose.JSONWebKeySet {
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	for _, key := range ring.Keys() {
+		set.Keys = append(set.Keys, key.JWK())
+	}
+	return set
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func newEncryptionKey(t *testing.T) string {
+	key := make([]byte, keyEncryptionKeyLength)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestSealSigningKey(t *testing.T) {
+	key, err := GenerateSigningKey(AlgorithmES256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptionKey := newEncryptionKey(t)
+
+	sealed, err := SealSigningKey(key, encryptionKey)
+	if err != nil {
+		t.Fatalf("SealSigningKey: %v", err)
+	}
+	encoded, err := EncodeSigningKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sealed, encoded) {
+		t.Fatal("sealed key contains the plain key")
+	}
+
+	opened, err := OpenSigningKey(sealed, encryptionKey)
+	if err != nil {
+		t.Fatalf("OpenSigningKey: %v", err)
+	}
+	if opened.ID != key.ID || opened.Algorithm != key.Algorithm {
+		t.Fatalf("opened %s %s, want %s %s", opened.ID, opened.Algorithm, key.ID, key.Algorithm)
+	}
+
+	if _, err := OpenSigningKey(sealed, newEncryptionKey(t)); err == nil {
+		t.Fatal("opened a key with the wrong encryption key")
+	}
+}
+
+func TestSealSigningKeyRejectsBadEncryptionKeys(t *testing.T) {
+	key, err := GenerateSigningKey(AlgorithmEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, encryptionKey := range []string{"", "not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := SealSigningKey(key, encryptionKey); err == nil {
+			t.Fatalf("sealed with encryption key %q", encryptionKey)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Shortest window a rate limit may use, the limiters count in milliseconds.
+const MinRateLimitWindow = time.Second
+
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Parses a rate limit in the form "<limit>/<window>", e.g. "5/1m".
+func ParseRateLimit(spec string) (RateLimit, error) {
+	parts := strings.Split(spec, "/")
+	if len(parts) != 2 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, expected <limit>/<window>", spec)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit < 1 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit count %q", parts[0])
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("invalid rate limit window %q", parts[1])
+	}
+	if window < MinRateLimitWindow {
+		return RateLimit{}, fmt.Errorf("rate limit window %q is shorter than %s", parts[1], MinRateLimitWindow)
+	}
+
+	return RateLimit{Limit: limit, Window: window}, nil
+}
+
+func MustParseRateLimit(spec string) RateLimit {
+	rateLimit, err := ParseRateLimit(spec)
+	if err != nil {
+		panic(err)
+	}
+	return rateLimit
+}
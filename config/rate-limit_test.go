@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    RateLimit
+		wantErr bool
+	}{
+		{"5/1m", RateLimit{Limit: 5, Window: time.Minute}, false},
+		{" 10 / 15m ", RateLimit{Limit: 10, Window: 15 * time.Minute}, false},
+		{"1/1s", RateLimit{Limit: 1, Window: time.Second}, false},
+		{"5/999ms", RateLimit{}, true},
+		{"5/1ns", RateLimit{}, true},
+		{"5/0s", RateLimit{}, true},
+		{"5/-1m", RateLimit{}, true},
+		{"0/1m", RateLimit{}, true},
+		{"-3/1m", RateLimit{}, true},
+		{"five/1m", RateLimit{}, true},
+		{"5/forever", RateLimit{}, true},
+		{"5", RateLimit{}, true},
+		{"5/1m/2", RateLimit{}, true},
+		{"", RateLimit{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			got, err := ParseRateLimit(test.spec)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRateLimit(%q) = %+v, want error", test.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRateLimit(%q): %v", test.spec, err)
+			}
+			if got != test.want {
+				t.Fatalf("ParseRateLimit(%q) = %+v, want %+v", test.spec, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	userService    services.IUserService
+	authService    services.IAuthService
+	accountService services.IAccountService
+}
+
+func NewAdminController(userService services.IUserService, authService services.IAuthService, accountService services.IAccountService) AdminController {
+	return AdminController{userService, authService, accountService}
+}
+
+func (ac *AdminController) GetUsers(ctx *gin.Context) {
+	var query models.UserQuery
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	users, total, err := ac.userService.FindUsers(&query)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{
+		"users": models.FilteredResponses(users),
+		"page":  query.Page,
+		"limit": query.Limit,
+		"total": total,
+	}})
+}
+
+func (ac *AdminController) GetUser(ctx *gin.Context) {
+	user, err := ac.userService.FindUserById(ctx.Params.ByName("id"))
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(user)}})
+}
+
+func (ac *AdminController) UpdateUserRole(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	userId := ctx.Params.ByName("id")
+	var input *models.UpdateRoleInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	//Prevent Admins Locking Themselves Out
+	if userId == currentUser.ID.Hex() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "you cannot change your own role"})
+		return
+	}
+
+	user, err := ac.userService.UpdateUserRole(userId, input.Role)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrInvalidRole) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "invalid role"})
+			return
+		}
+		if errors.Is(err, services.ErrUserIDNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(user)}})
+}
+
+func (ac *AdminController) VerifyUser(ctx *gin.Context) {
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	user, err := ac.userService.MarkUserVerified(ctx.Params.ByName("id"), config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(user)}})
+}
+
+func (ac *AdminController) SuspendUser(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	userId := ctx.Params.ByName("id")
+	var input *models.SuspendUserInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	if userId == currentUser.ID.Hex() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "you cannot suspend your own account"})
+		return
+	}
+
+	user, err := ac.userService.SetUserStatus(userId, models.StatusSuspended, input.Reason)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+		return
+	}
+
+	//Sign Out Everywhere
+	if err := ac.authService.LogoutAllDevices(userId); err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(user)}})
+}
+
+func (ac *AdminController) ReactivateUser(ctx *gin.Context) {
+	user, err := ac.userService.SetUserStatus(ctx.Params.ByName("id"), models.StatusActive, "")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(user)}})
+}
+
+func (ac *AdminController) ResetUserPassword(ctx *gin.Context) {
+	userId := ctx.Params.ByName("id")
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	resetErr := ac.userService.ForcePasswordReset(userId, config)
+	if resetErr != nil {
+		go utils.LogError(resetErr, ctx)
+		if errors.Is(resetErr, services.ErrUserIDNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+			return
+		}
+		if !errors.Is(resetErr, services.ErrSendingEmail) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+			return
+		}
+	}
+
+	//Sessions Go Even When the Email Fails
+	if err := ac.authService.LogoutAllDevices(userId); err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Password Cleared, but Email Not Sent
+	if resetErr != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"status": "success", "message": "there was an error sending email"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "password reset email sent"})
+}
+
+func (ac *AdminController) DeleteUser(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	userId := ctx.Params.ByName("id")
+
+	if userId == currentUser.ID.Hex() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "you cannot delete your own account here"})
+		return
+	}
+
+	err := ac.accountService.PurgeAccount(userId)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrUserIDNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "user not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "user deleted"})
+}
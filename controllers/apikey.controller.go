@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService services.IAPIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.IAPIKeyService) APIKeyController {
+	return APIKeyController{apiKeyService}
+}
+
+func (ac *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input *models.CreateAPIKeyInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	apiKey, err := ac.apiKeyService.CreateAPIKey(currentUser, input)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrInvalidAPIKeyInput) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "scopes must be read or write and expiry must be in the future"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": gin.H{"api_key": apiKey}})
+}
+
+func (ac *APIKeyController) GetAPIKeys(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	apiKeys, err := ac.apiKeyService.FindAPIKeysByUserId(currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"api_keys": apiKeys}})
+}
+
+func (ac *APIKeyController) DeleteAPIKey(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	err := ac.apiKeyService.RevokeAPIKey(ctx.Params.ByName("id"), currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		//API Key Not Found
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "api key not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "api key revoked"})
+}
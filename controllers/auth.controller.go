@@ -11,13 +11,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Carries the pending second factor of redirect based sign-ins, such as OAuth.
+const (
+	mfaTokenCookie     = "mfa_token"
+	mfaTokenCookiePath = "/api/auth/login/mfa"
+)
+
 type AuthController struct {
-	authService services.IAuthService
-	userService services.IUserService
+	authService    services.IAuthService
+	userService    services.IUserService
+	accountService services.IAccountService
 }
 
-func NewAuthController(authService services.IAuthService, userService services.IUserService) AuthController {
-	return AuthController{authService, userService}
+func NewAuthController(authService services.IAuthService, userService services.IUserService, accountService services.IAccountService) AuthController {
+	return AuthController{authService, userService, accountService}
 }
 
 func (ac *AuthController) SignUpUser(ctx *gin.Context) {
@@ -37,6 +44,10 @@ func (ac *AuthController) SignUpUser(ctx *gin.Context) {
 
 	if err != nil {
 		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "password does not meet the requirements", "errors": passwordViolations(err)})
+			return
+		}
 		if errors.Is(err, services.ErrCreatingUser) {
 			ctx.JSON(http.StatusConflict, gin.H{"status": "error", "message": "could not create your account"})
 			return
@@ -90,15 +101,25 @@ func (ac *AuthController) SignInUser(ctx *gin.Context) {
 		return
 	}
 
-	access_token, refresh_token, err := ac.authService.SignInUser(credentials, config)
+	tokens, err := ac.authService.SignInUser(credentials, utils.ExtractClientInfo(ctx), config)
 
 	if err != nil {
 		go utils.LogError(err, ctx)
-		//User Not Found || Incorrect Password
-		if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrIncorrectPassword) {
+		//Locked Out, Unknown Emails Lock the Same Way so Emails Are Not Revealed
+		if errors.Is(err, services.ErrAccountLocked) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many attempts, try again later"})
+			return
+		}
+		//User Not Found || Incorrect Password || Deleted
+		if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrIncorrectPassword) || errors.Is(err, services.ErrAccountDeleted) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "invalid email or password"})
 			return
 		}
+		//Suspended
+		if errors.Is(err, services.ErrAccountSuspended) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been suspended"})
+			return
+		}
 		//Not Verified
 		if errors.Is(err, services.ErrUserNotVerified) {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "you are not verified, please verify your email to login"})
@@ -106,16 +127,157 @@ func (ac *AuthController) SignInUser(ctx *gin.Context) {
 		}
 
 		//Failed to Generate Tokens
-		if errors.Is(err, services.ErrGeneratingToken) {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	//Second Factor Required
+	if tokens.MFAToken != "" {
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "mfa_required": true, "mfa_token": tokens.MFAToken})
+		return
+	}
+
+	setAuthCookies(ctx, config, tokens.AccessToken, tokens.RefreshToken)
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": tokens.AccessToken})
+}
+
+func (ac *AuthController) RequestMagicLink(ctx *gin.Context) {
+	var input *models.MagicLinkInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	err = ac.authService.SendMagicLink(input.Email, config)
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		//A Failed Send Would Reveal the Account Exists
+		if !errors.Is(err, services.ErrSendingEmail) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
 			return
 		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "you will receive a sign-in link if a verified account with that email exists"})
+}
+
+func (ac *AuthController) SignInWithMagicLink(ctx *gin.Context) {
+	token := ctx.Params.ByName("token")
 
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
 	}
 
-	ctx.SetCookie("access_token", access_token, config.AccessTokenMaxAge*60, "/", config.Origin, false, true)
-	ctx.SetCookie("refresh_token", refresh_token, config.RefreshTokenMaxAge*60, "/", config.Origin, false, true)
-	ctx.SetCookie("logged_in", "true", config.AccessTokenMaxAge*60, "/", config.Origin, false, false)
+	tokens, err := ac.authService.SignInWithMagicLink(token, utils.ExtractClientInfo(ctx), config)
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		//Expired, Used or Unknown Link
+		if errors.Is(err, services.ErrInvalidMagicLink) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "sign-in link is invalid or has expired"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many attempts, try again later"})
+			return
+		}
+		//Suspended or Deleted
+		if errors.Is(err, services.ErrAccountSuspended) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been suspended"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountDeleted) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been deleted"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	//Second Factor Required
+	if tokens.MFAToken != "" {
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "mfa_required": true, "mfa_token": tokens.MFAToken})
+		return
+	}
+
+	setAuthCookies(ctx, config, tokens.AccessToken, tokens.RefreshToken)
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": tokens.AccessToken})
+}
+
+func (ac *AuthController) VerifyMFA(ctx *gin.Context) {
+	var input *models.MFALoginInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	if input.MFAToken == "" {
+		input.MFAToken, _ = ctx.Cookie(mfaTokenCookie)
+	}
+	if input.MFAToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "missing mfa token"})
+		return
+	}
+
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	access_token, refresh_token, err := ac.authService.VerifyMFA(input, utils.ExtractClientInfo(ctx), config)
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		//Expired or Unknown Challenge
+		if errors.Is(err, services.ErrInvalidMFAToken) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "sign-in session expired, please sign in again"})
+			return
+		}
+		//Wrong Code
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "invalid authentication code"})
+			return
+		}
+		//Too Many Failed Codes or Passwords
+		if errors.Is(err, services.ErrAccountLocked) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many attempts, try again later"})
+			return
+		}
+		//Suspended or Deleted
+		if errors.Is(err, services.ErrAccountSuspended) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been suspended"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountDeleted) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been deleted"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.SetCookie(mfaTokenCookie, "", -1, mfaTokenCookiePath, config.Origin, false, true)
+	setAuthCookies(ctx, config, access_token, refresh_token)
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": access_token})
 }
@@ -139,7 +301,7 @@ func (ac *AuthController) RefreshAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	access_token, err := ac.authService.RefreshAccessToken(refresh_token, config)
+	access_token, new_refresh_token, err := ac.authService.RefreshAccessToken(refresh_token, config)
 
 	if err != nil {
 		go utils.LogError(err, ctx)
@@ -149,22 +311,41 @@ func (ac *AuthController) RefreshAccessToken(ctx *gin.Context) {
 			return
 		}
 
+		//Reused Token, Session Revoked
+		if errors.Is(err, services.ErrRefreshTokenReused) {
+			clearAuthCookies(ctx, config)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": message})
+			return
+		}
+
+		//Revoked Token
+		if errors.Is(err, services.ErrTokenRevoked) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": message})
+			return
+		}
+
 		//User Not Found
 		if errors.Is(err, services.ErrUserNotFound) {
 			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "the user belonging to this token no logger exists"})
 			return
 		}
 
+		//Suspended or Deleted
+		if errors.Is(err, services.ErrAccountSuspended) || errors.Is(err, services.ErrAccountDeleted) {
+			clearAuthCookies(ctx, config)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": message})
+			return
+		}
+
 		//Failed to Create Token
-		if errors.Is(err, services.ErrGeneratingToken) {
+		if errors.Is(err, services.ErrGeneratingToken) || errors.Is(err, services.ErrStoringToken) || errors.Is(err, services.ErrReadingTokenState) {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
 			return
 		}
 
 	}
 
-	ctx.SetCookie("access_token", access_token, config.AccessTokenMaxAge*60, "/", config.Origin, false, true)
-	ctx.SetCookie("logged_in", "true", config.AccessTokenMaxAge*60, "/", config.Origin, false, false)
+	setAuthCookies(ctx, config, access_token, new_refresh_token)
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": access_token})
 }
@@ -172,18 +353,50 @@ func (ac *AuthController) RefreshAccessToken(ctx *gin.Context) {
 func (ac *AuthController) LogoutUser(ctx *gin.Context) {
 	config, _ := config.LoadConfig(".")
 
-	ctx.SetCookie("access_token", "", -1, "/", config.Origin, false, true)
-	ctx.SetCookie("refresh_token", "", -1, "/", config.Origin, false, true)
-	ctx.SetCookie("logged_in", "", -1, "/", config.Origin, false, true)
+	access_token := utils.ExtractAccessToken(ctx)
+	refresh_token, _ := ctx.Cookie("refresh_token")
+
+	err := ac.authService.LogoutUser(access_token, refresh_token, config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	clearAuthCookies(ctx, config)
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
+func (ac *AuthController) LogoutAllDevices(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	config, _ := config.LoadConfig(".")
+
+	err := ac.authService.LogoutAllDevices(currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	clearAuthCookies(ctx, config)
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "logged out of all devices"})
+}
+
 func (ac *AuthController) VerifyEmail(ctx *gin.Context) {
 
 	code := ctx.Params.ByName("verificationCode")
 
-	err := ac.userService.VerifyUserEmail(code)
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	err = ac.userService.VerifyUserEmail(code, config)
 	if err != nil {
 		go utils.LogError(err, ctx)
 		ctx.JSON(http.StatusForbidden, gin.H{"status": "success", "message": "could not verify email address"})
@@ -194,6 +407,97 @@ func (ac *AuthController) VerifyEmail(ctx *gin.Context) {
 
 }
 
+func (ac *AuthController) ResendVerificationEmail(ctx *gin.Context) {
+	var input *models.ResendVerificationInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	message := "you will receive a verification email if an unverified user with that email exists"
+
+	user, err := ac.userService.FindUserByEmail(input.Email)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrUserEmailNotFound) {
+			ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": message})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Verified, Suspended or Deleted, Respond as if Sent
+	if user.Verified || !user.IsActive() {
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": message})
+		return
+	}
+
+	err = ac.userService.SendVerificationEmail(user)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		//A Failed Send Would Reveal the Account Exists
+		if !errors.Is(err, services.ErrSendingEmail) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": message})
+}
+
+func (ac *AuthController) ConfirmEmailChange(ctx *gin.Context) {
+	emailChangeToken := ctx.Params.ByName("emailChangeToken")
+
+	err := ac.userService.ConfirmEmailChange(emailChangeToken)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrEmailInUse) {
+			ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "email already in use"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "token is invalid or has expired"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "email changed successfully"})
+}
+
+func (ac *AuthController) UnlockAccount(ctx *gin.Context) {
+	unlockToken := ctx.Params.ByName("unlockToken")
+
+	err := ac.authService.UnlockAccount(unlockToken)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrUnlockTokenNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "token is invalid or has already been used"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "account unlocked successfully"})
+}
+
+func (ac *AuthController) RestoreAccount(ctx *gin.Context) {
+	restoreToken := ctx.Params.ByName("restoreToken")
+
+	err := ac.accountService.RestoreAccount(restoreToken)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrRestoreTokenNotFound) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "token is invalid or the account can no longer be restored"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "account restored successfully, you can sign in again"})
+}
+
 func (ac *AuthController) ForgotPassword(ctx *gin.Context) {
 	var userCredential *models.ForgotPasswordInput
 
@@ -220,6 +524,12 @@ func (ac *AuthController) ForgotPassword(ctx *gin.Context) {
 		return
 	}
 
+	//Suspended or Deleted, Respond as if Sent
+	if !user.IsActive() {
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": message})
+		return
+	}
+
 	config, err := config.LoadConfig(".")
 	if err != nil {
 		go utils.LogError(err, ctx)
@@ -261,6 +571,10 @@ func (ac *AuthController) ResetPassword(ctx *gin.Context) {
 
 	if err != nil {
 		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "password does not meet the requirements", "errors": passwordViolations(err)})
+			return
+		}
 		if errors.Is(err, services.ErrResetTokenNotFound) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"status": "success", "message": "token is invalid or has expired"})
 			return
@@ -277,3 +591,24 @@ func (ac *AuthController) ResetPassword(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "password data updated successfully"})
 }
+
+func setAuthCookies(ctx *gin.Context, config *config.Config, access_token string, refresh_token string) {
+	ctx.SetCookie("access_token", access_token, config.AccessTokenMaxAge*60, "/", config.Origin, false, true)
+	ctx.SetCookie("refresh_token", refresh_token, config.RefreshTokenMaxAge*60, "/", config.Origin, false, true)
+	ctx.SetCookie("logged_in", "true", config.AccessTokenMaxAge*60, "/", config.Origin, false, false)
+}
+
+// Extracts the broken password rules from a service error.
+func passwordViolations(err error) []models.PasswordViolation {
+	var policyErr *services.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return policyErr.Violations
+	}
+	return nil
+}
+
+func clearAuthCookies(ctx *gin.Context, config *config.Config) {
+	ctx.SetCookie("access_token", "", -1, "/", config.Origin, false, true)
+	ctx.SetCookie("refresh_token", "", -1, "/", config.Origin, false, true)
+	ctx.SetCookie("logged_in", "", -1, "/", config.Origin, false, true)
+}
@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct{}
+
+func NewJWKSController() JWKSController {
+	return JWKSController{}
+}
+
+// Serves the public keys access tokens may be verified with, including keys
+// published ahead of activation. Keys are published at least the max-age
+// before they sign, so a cached copy never misses the current key.
+func (jc *JWKSController) GetJWKS(ctx *gin.Context) {
+	config, _ := config.LoadConfig(".")
+
+	ctx.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(config.JWKSMaxAge.Seconds())))
+	ctx.JSON(http.StatusOK, config.AccessTokenKeys.JWKS())
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	mfaService services.IMFAService
+}
+
+func NewMFAController(mfaService services.IMFAService) MFAController {
+	return MFAController{mfaService}
+}
+
+func (mc *MFAController) EnrollTOTP(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	enrollment, err := mc.mfaService.EnrollTOTP(currentUser, config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrMFAAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "two-factor authentication is already enabled"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": enrollment})
+}
+
+func (mc *MFAController) ConfirmTOTP(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input *models.MFACodeInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	recoveryCodes, err := mc.mfaService.ConfirmTOTP(currentUser, input.Code)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrMFAAlreadyEnabled) {
+			ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "two-factor authentication is already enabled"})
+			return
+		}
+		if errors.Is(err, services.ErrMFANotEnrolled) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "start enrollment before confirming"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "invalid authentication code"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"recovery_codes": recoveryCodes}})
+}
+
+func (mc *MFAController) DisableTOTP(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input *models.MFADisableInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	err = mc.mfaService.DisableTOTP(currentUser, input, config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrMFANotEnabled) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "two-factor authentication is not enabled"})
+			return
+		}
+		//Too Many Failed Codes or Passwords
+		if errors.Is(err, services.ErrAccountLocked) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many attempts, try again later"})
+			return
+		}
+		if errors.Is(err, services.ErrIncorrectPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "incorrect password"})
+			return
+		}
+		if errors.Is(err, services.ErrInvalidMFACode) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "invalid authentication code"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "two-factor authentication disabled"})
+}
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const oauthStateCookie = "oauth_state"
+
+type OAuthController struct {
+	oauthService services.IOAuthService
+	authService  services.IAuthService
+}
+
+func NewOAuthController(oauthService services.IOAuthService, authService services.IAuthService) OAuthController {
+	return OAuthController{oauthService, authService}
+}
+
+func (oc *OAuthController) BeginLogin(ctx *gin.Context) {
+	config, _ := config.LoadConfig(".")
+
+	authURL, state, err := oc.oauthService.BeginLogin(ctx.Params.ByName("provider"), config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrOAuthProviderNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "unknown sign-in provider"})
+			return
+		}
+		if errors.Is(err, services.ErrOAuthExchange) {
+			ctx.JSON(http.StatusBadGateway, gin.H{"status": "error", "message": "sign-in provider unavailable"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Bind State to This Browser
+	ctx.SetCookie(oauthStateCookie, state, int(config.OAuthStateExpiresIn.Seconds()), "/", config.Origin, false, true)
+
+	ctx.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+func (oc *OAuthController) Callback(ctx *gin.Context) {
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	if ctx.Query("error") != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "sign-in was cancelled or denied"})
+		return
+	}
+
+	state := ctx.Query("state")
+	cookieState, err := ctx.Cookie(oauthStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(cookieState)) != 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "sign-in expired, please try again"})
+		return
+	}
+	ctx.SetCookie(oauthStateCookie, "", -1, "/", config.Origin, false, true)
+
+	user, err := oc.oauthService.FinishLogin(ctx.Params.ByName("provider"), state, ctx.Query("code"), config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrOAuthProviderNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "unknown sign-in provider"})
+			return
+		}
+		if errors.Is(err, services.ErrOAuthStateInvalid) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "sign-in expired, please try again"})
+			return
+		}
+		if errors.Is(err, services.ErrOAuthEmailNotVerified) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your provider account has no verified email"})
+			return
+		}
+		if errors.Is(err, services.ErrOAuthExchange) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "could not sign in with provider"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many attempts, try again later"})
+			return
+		}
+		if errors.Is(err, services.ErrIdentityConflict) {
+			ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "this account is already linked to another login from this provider"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	tokens, err := oc.authService.CompleteSignIn(user, utils.ExtractClientInfo(ctx), config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrAccountSuspended) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been suspended"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountDeleted) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been deleted"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Second Factor Required, Token Kept Out of the URL
+	if tokens.MFAToken != "" {
+		ctx.SetCookie(mfaTokenCookie, tokens.MFAToken, int(config.MFAChallengeExpiresIn.Seconds()), mfaTokenCookiePath, config.Origin, false, true)
+		ctx.Redirect(http.StatusFound, config.Origin+"/login/mfa")
+		return
+	}
+
+	setAuthCookies(ctx, config, tokens.AccessToken, tokens.RefreshToken)
+
+	ctx.Redirect(http.StatusFound, config.Origin)
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	sessionService services.ISessionService
+}
+
+func NewSessionController(sessionService services.ISessionService) SessionController {
+	return SessionController{sessionService}
+}
+
+func (sc *SessionController) GetSessions(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	sessions, err := sc.sessionService.FindSessionsByUserId(currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"sessions": sessions}})
+}
+
+func (sc *SessionController) DeleteSession(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	sessionId := ctx.Params.ByName("id")
+
+	err := sc.sessionService.RevokeSession(sessionId, currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		//Session Not Found
+		if errors.Is(err, services.ErrSessionNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "session not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "session revoked"})
+}
@@ -1,19 +1,25 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
+	"github.com/AmadoJunior/Gipitty/config"
 	"github.com/AmadoJunior/Gipitty/models"
 	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
 	"github.com/gin-gonic/gin"
 )
 
 type UserController struct {
-	userService services.IUserService
+	userService    services.IUserService
+	authService    services.IAuthService
+	accountService services.IAccountService
+	exportService  services.IExportService
 }
 
-func NewUserController(userService services.IUserService) UserController {
-	return UserController{userService}
+func NewUserController(userService services.IUserService, authService services.IAuthService, accountService services.IAccountService, exportService services.IExportService) UserController {
+	return UserController{userService, authService, accountService, exportService}
 }
 
 func (uc *UserController) GetMe(ctx *gin.Context) {
@@ -21,3 +27,213 @@ func (uc *UserController) GetMe(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(currentUser)}})
 }
+
+func (uc *UserController) UpdateMe(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input models.UpdateProfileInput
+
+	if err := utils.BindStrictJSON(ctx, &input); err != nil {
+		if fields := utils.ValidationErrors(err, &input); fields != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "invalid profile", "errors": fields})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	user, err := uc.userService.UpdateProfile(currentUser.ID.Hex(), &input)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrInvalidProfileInput) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "invalid profile", "errors": gin.H{"name": "must not be blank"}})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"user": models.FilteredResponse(user)}})
+}
+
+func (uc *UserController) ChangePassword(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input *models.ChangePasswordInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	if input.Password != input.PasswordConfirm {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "passwords do not match"})
+		return
+	}
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	changeErr := uc.userService.ChangePassword(currentUser, input, config)
+	if changeErr != nil && !errors.Is(changeErr, services.ErrSendingEmail) {
+		go utils.LogError(changeErr, ctx)
+		if errors.Is(changeErr, services.ErrIncorrectPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "incorrect password"})
+			return
+		}
+		if errors.Is(changeErr, services.ErrWeakPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "password does not meet the requirements", "errors": passwordViolations(changeErr)})
+			return
+		}
+		if errors.Is(changeErr, services.ErrPasswordUnchanged) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "new password must be different from the current one"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Sign Out Everywhere, Then Start a Fresh Session Here
+	if err := uc.authService.LogoutAllDevices(currentUser.ID.Hex()); err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	access_token, refresh_token, err := uc.authService.IssueTokens(currentUser, utils.ExtractClientInfo(ctx), config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		clearAuthCookies(ctx, config)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "your password was changed, please sign in again"})
+		return
+	}
+	setAuthCookies(ctx, config, access_token, refresh_token)
+
+	//Changed, but Notification Not Sent
+	if changeErr != nil {
+		go utils.LogError(changeErr, ctx)
+		ctx.JSON(http.StatusBadGateway, gin.H{"status": "success", "message": "your password was changed, but there was an error sending email", "access_token": access_token})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "your password was changed", "access_token": access_token})
+}
+
+func (uc *UserController) ChangeEmail(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input *models.ChangeEmailInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	err = uc.userService.RequestEmailChange(currentUser, input, config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrIncorrectPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "incorrect password"})
+			return
+		}
+		if errors.Is(err, services.ErrEmailUnchanged) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "that is already your email address"})
+			return
+		}
+		if errors.Is(err, services.ErrEmailInUse) {
+			ctx.JSON(http.StatusConflict, gin.H{"status": "fail", "message": "email already in use"})
+			return
+		}
+		if errors.Is(err, services.ErrSendingEmail) {
+			ctx.JSON(http.StatusBadGateway, gin.H{"status": "success", "message": "there was an error sending email"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "we sent a confirmation link to " + input.Email})
+}
+
+func (uc *UserController) DeleteMe(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+	var input *models.DeleteAccountInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": err.Error()})
+		return
+	}
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	deleteErr := uc.accountService.DeleteAccount(currentUser, input.Password, config)
+	if deleteErr != nil && !errors.Is(deleteErr, services.ErrSendingEmail) {
+		go utils.LogError(deleteErr, ctx)
+		if errors.Is(deleteErr, services.ErrIncorrectPassword) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "incorrect password"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Sign Out Everywhere
+	if err := uc.authService.LogoutAllDevices(currentUser.ID.Hex()); err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+	clearAuthCookies(ctx, config)
+
+	//Deleted, but Confirmation Not Sent
+	if deleteErr != nil {
+		go utils.LogError(deleteErr, ctx)
+		ctx.JSON(http.StatusBadGateway, gin.H{"status": "success", "message": "your account was deleted, but there was an error sending email"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "your account was deleted, we sent an email with a link to restore it"})
+}
+
+func (uc *UserController) RequestExport(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	config, err := config.LoadConfig(".")
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	uc.exportService.RequestExport(currentUser, config)
+
+	ctx.JSON(http.StatusAccepted, gin.H{"status": "success", "message": "we are preparing your data and will email you a download link when it is ready"})
+}
+
+func (uc *UserController) DownloadExport(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	export, err := uc.exportService.FindExport(ctx.Params.ByName("token"), currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "export not found or has expired"})
+		return
+	}
+
+	ctx.Header("Content-Disposition", "attachment; filename=gipitty-export-"+export.CreatedAt.Format("2006-01-02")+".zip")
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "application/zip", export.Archive)
+}
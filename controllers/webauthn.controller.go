@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const webAuthnSessionCookie = "webauthn_session"
+
+type WebAuthnController struct {
+	webAuthnService services.IWebAuthnService
+	authService     services.IAuthService
+}
+
+func NewWebAuthnController(webAuthnService services.IWebAuthnService, authService services.IAuthService) WebAuthnController {
+	return WebAuthnController{webAuthnService, authService}
+}
+
+func (wc *WebAuthnController) BeginRegistration(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	creation, err := wc.webAuthnService.BeginRegistration(currentUser)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, creation)
+}
+
+func (wc *WebAuthnController) FinishRegistration(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	credential, err := wc.webAuthnService.FinishRegistration(currentUser, ctx.Query("name"), ctx.Request)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrWebAuthnSessionExpired) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "registration expired, please try again"})
+			return
+		}
+		if errors.Is(err, services.ErrWebAuthnCeremony) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "could not register passkey"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": "success", "data": gin.H{"credential": credential}})
+}
+
+func (wc *WebAuthnController) BeginLogin(ctx *gin.Context) {
+	config, _ := config.LoadConfig(".")
+
+	assertion, sessionID, err := wc.webAuthnService.BeginLogin()
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.SetCookie(webAuthnSessionCookie, sessionID, 5*60, "/", config.Origin, false, true)
+
+	ctx.JSON(http.StatusOK, assertion)
+}
+
+func (wc *WebAuthnController) FinishLogin(ctx *gin.Context) {
+	config, err := config.LoadConfig(".")
+
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "success", "message": "internal server error"})
+		return
+	}
+
+	sessionID, err := ctx.Cookie(webAuthnSessionCookie)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "sign-in expired, please try again"})
+		return
+	}
+	ctx.SetCookie(webAuthnSessionCookie, "", -1, "/", config.Origin, false, true)
+
+	user, err := wc.webAuthnService.FinishLogin(sessionID, ctx.Request)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrWebAuthnSessionExpired) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"status": "fail", "message": "sign-in expired, please try again"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountLocked) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many attempts, try again later"})
+			return
+		}
+		if errors.Is(err, services.ErrUserNotVerified) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "you are not verified, please verify your email to login"})
+			return
+		}
+		if errors.Is(err, services.ErrWebAuthnCeremony) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "could not verify passkey"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Same Status and Second Factor Checks as Every Other Sign-In
+	tokens, err := wc.authService.CompleteSignIn(user, utils.ExtractClientInfo(ctx), config)
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrAccountSuspended) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been suspended"})
+			return
+		}
+		if errors.Is(err, services.ErrAccountDeleted) {
+			ctx.JSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been deleted"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	//Second Factor Required
+	if tokens.MFAToken != "" {
+		ctx.JSON(http.StatusOK, gin.H{"status": "success", "mfa_required": true, "mfa_token": tokens.MFAToken})
+		return
+	}
+
+	setAuthCookies(ctx, config, tokens.AccessToken, tokens.RefreshToken)
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "access_token": tokens.AccessToken})
+}
+
+func (wc *WebAuthnController) GetCredentials(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	credentials, err := wc.webAuthnService.FindCredentialsByUserId(currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "data": gin.H{"credentials": credentials}})
+}
+
+func (wc *WebAuthnController) DeleteCredential(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(*models.DBResponse)
+
+	err := wc.webAuthnService.DeleteCredential(ctx.Params.ByName("id"), currentUser.ID.Hex())
+	if err != nil {
+		go utils.LogError(err, ctx)
+		if errors.Is(err, services.ErrCredentialNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"status": "fail", "message": "passkey not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": "success", "message": "passkey removed"})
+}
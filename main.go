@@ -8,9 +8,11 @@ import (
 
 	"github.com/AmadoJunior/Gipitty/config"
 	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
 	"github.com/AmadoJunior/Gipitty/repos"
 	"github.com/AmadoJunior/Gipitty/routes"
 	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/AmadoJunior/Gipitty/utils"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/contrib/static"
 	"github.com/gin-gonic/gin"
@@ -24,17 +26,47 @@ var (
 	ctx         context.Context
 	redisClient *redis.Client
 	mongoClient *mongo.Client
-
-	userRepository repos.IUserRepo
-
-	userService services.IUserService
-	authService services.IAuthService
-
-	AuthController controllers.AuthController
-	UserController controllers.UserController
-
-	AuthRouteController routes.AuthRouteController
-	UserRouteController routes.UserRouteController
+	rateLimiter middleware.RateLimiter
+
+	userRepository       repos.IUserRepo
+	tokenRepository      repos.ITokenRepo
+	sessionRepository    repos.ISessionRepo
+	credentialRepository repos.ICredentialRepo
+	apiKeyRepository     repos.IAPIKeyRepo
+	signingKeyRepository repos.ISigningKeyRepo
+	exportRepository     repos.IExportRepo
+	migrationRepository  repos.IMigrationRepo
+
+	userService       services.IUserService
+	authService       services.IAuthService
+	sessionService    services.ISessionService
+	mfaService        services.IMFAService
+	webAuthnService   services.IWebAuthnService
+	oauthService      services.IOAuthService
+	apiKeyService     services.IAPIKeyService
+	signingKeyService services.ISigningKeyService
+	accountService    services.IAccountService
+	exportService     services.IExportService
+
+	AuthController     controllers.AuthController
+	UserController     controllers.UserController
+	SessionController  controllers.SessionController
+	MFAController      controllers.MFAController
+	WebAuthnController controllers.WebAuthnController
+	OAuthController    controllers.OAuthController
+	APIKeyController   controllers.APIKeyController
+	JWKSController     controllers.JWKSController
+	AdminController    controllers.AdminController
+
+	AuthRouteController     routes.AuthRouteController
+	UserRouteController     routes.UserRouteController
+	SessionRouteController  routes.SessionRouteController
+	MFARouteController      routes.MFARouteController
+	WebAuthnRouteController routes.WebAuthnRouteController
+	OAuthRouteController    routes.OAuthRouteController
+	APIKeyRouteController   routes.APIKeyRouteController
+	JWKSRouteController     routes.JWKSRouteController
+	AdminRouteController    routes.AdminRouteController
 )
 
 func init() {
@@ -89,15 +121,126 @@ func init() {
 		panic(err)
 	}
 
-	//Auth
-	userService = services.NewUserService(userRepository, ctx)
-	authService = services.NewAuthService(userRepository, ctx)
+	//Init Token Repo
+	tokenRepository = repos.NewTokenRepo(ctx)
+	err = tokenRepository.InitRepository(redisClient)
+	if err != nil {
+		panic(err)
+	}
+
+	//Init Session Repo
+	sessionRepository = repos.NewSessionRepo(ctx)
+	err = sessionRepository.InitRepository(mongoClient, "Gipitty", "sessions")
+	if err != nil {
+		panic(err)
+	}
+
+	//Init Credential Repo
+	credentialRepository = repos.NewCredentialRepo(ctx)
+	err = credentialRepository.InitRepository(mongoClient, "Gipitty", "credentials")
+	if err != nil {
+		panic(err)
+	}
+
+	//Init API Key Repo
+	apiKeyRepository = repos.NewAPIKeyRepo(ctx)
+	err = apiKeyRepository.InitRepository(mongoClient, "Gipitty", "apikeys")
+	if err != nil {
+		panic(err)
+	}
+
+	//Init Signing Key Repo
+	signingKeyRepository = repos.NewSigningKeyRepo(ctx)
+	err = signingKeyRepository.InitRepository(mongoClient, "Gipitty", "signing_keys")
+	if err != nil {
+		panic(err)
+	}
+
+	//Init Export Repo
+	exportRepository = repos.NewExportRepo(ctx)
+	err = exportRepository.InitRepository(mongoClient, "Gipitty", "exports")
+	if err != nil {
+		panic(err)
+	}
 
-	AuthController = controllers.NewAuthController(authService, userService)
-	UserController = controllers.NewUserController(userService)
+	//Init Migration Repo
+	migrationRepository = repos.NewMigrationRepo(ctx)
+	err = migrationRepository.InitRepository(mongoClient, "Gipitty", "migrations")
+	if err != nil {
+		panic(err)
+	}
+
+	//One-Time Tokens, Digests of Outstanding Legacy Tokens
+	utils.UseTokenPepper(config.TokenPepper)
+	err = services.MigrateOneTimeTokens(migrationRepository, userRepository, exportRepository, config)
+	if err != nil {
+		panic(err)
+	}
+
+	//Token Signing Keys
+	signingKeyService = services.NewSigningKeyService(signingKeyRepository, config, ctx)
+	err = signingKeyService.Seed()
+	if err != nil {
+		panic(err)
+	}
+	signingKeyService.Start()
+
+	//Password Hashing
+	passwordHasher, err := services.NewPasswordHasher(config)
+	if err != nil {
+		panic(err)
+	}
+
+	//Password Policy
+	passwordRules, err := services.PasswordRulesFromConfig(config)
+	if err != nil {
+		panic(err)
+	}
+	passwordPolicy := services.NewPasswordPolicy(passwordRules...)
+
+	//Auth
+	userService = services.NewUserService(userRepository, passwordPolicy, passwordHasher, ctx)
+	authService = services.NewAuthService(userRepository, tokenRepository, sessionRepository, passwordPolicy, passwordHasher, ctx)
+	sessionService = services.NewSessionService(sessionRepository, tokenRepository, ctx)
+	mfaService = services.NewMFAService(userRepository, passwordHasher, ctx)
+	webAuthnService, err = services.NewWebAuthnService(userRepository, credentialRepository, tokenRepository, config, ctx)
+	if err != nil {
+		panic(err)
+	}
+	apiKeyService = services.NewAPIKeyService(apiKeyRepository, ctx)
+	oauthService = services.NewOAuthService(userRepository, tokenRepository, services.NewOAuthProviders(config), passwordHasher, ctx)
+	accountService = services.NewAccountService(userRepository, sessionRepository, credentialRepository, apiKeyRepository, exportRepository, tokenRepository, passwordHasher, config, ctx)
+	accountService.Start()
+	exportService = services.NewExportService(userRepository, sessionRepository, credentialRepository, apiKeyRepository, exportRepository, ctx)
+
+	//Bootstrap Admins
+	err = userService.BootstrapAdmins(config.AdminEmails)
+	if err != nil {
+		panic(err)
+	}
 
-	AuthRouteController = routes.NewAuthRouteController(AuthController)
-	UserRouteController = routes.NewRouteUserController(UserController, userService)
+	AuthController = controllers.NewAuthController(authService, userService, accountService)
+	UserController = controllers.NewUserController(userService, authService, accountService, exportService)
+	SessionController = controllers.NewSessionController(sessionService)
+	MFAController = controllers.NewMFAController(mfaService)
+	WebAuthnController = controllers.NewWebAuthnController(webAuthnService, authService)
+	OAuthController = controllers.NewOAuthController(oauthService, authService)
+	APIKeyController = controllers.NewAPIKeyController(apiKeyService)
+	JWKSController = controllers.NewJWKSController()
+	AdminController = controllers.NewAdminController(userService, authService, accountService)
+
+	//Rate Limiting
+	rateLimiter = middleware.NewRateLimiter(ctx, redisClient)
+
+	AuthRouteController = routes.NewAuthRouteController(AuthController, userService, authService, apiKeyService, rateLimiter)
+	UserRouteController = routes.NewRouteUserController(UserController, userService, authService, apiKeyService, rateLimiter)
+	SessionRouteController = routes.NewSessionRouteController(SessionController, userService, authService, apiKeyService)
+	MFARouteController = routes.NewMFARouteController(MFAController, userService, authService, apiKeyService, rateLimiter)
+	WebAuthnRouteController = routes.NewWebAuthnRouteController(WebAuthnController, userService, authService, apiKeyService, rateLimiter)
+	OAuthRouteController = routes.NewOAuthRouteController(OAuthController, rateLimiter)
+	APIKeyRouteController = routes.NewAPIKeyRouteController(APIKeyController, userService, authService, apiKeyService)
+	JWKSRouteController = routes.NewJWKSRouteController(JWKSController)
+	AdminRouteController = routes.NewAdminRouteController(AdminController, userService, authService, apiKeyService)
 
 	//Gin Server
 	server = gin.Default()
@@ -111,6 +254,7 @@ func main() {
 	}
 
 	defer userRepository.DeinitRepository()
+	defer tokenRepository.DeinitRepository()
 
 	value, err := redisClient.Get(ctx, "test").Result()
 	if err == redis.Nil {
@@ -128,6 +272,9 @@ func main() {
 	//Static
 	server.Use(static.Serve("/", static.LocalFile("/home/amado/Documents/Gipitty/public", true)))
 
+	//Well-Known
+	JWKSRouteController.JWKSRoute(&server.RouterGroup)
+
 	//API
 	router := server.Group("/api")
 	router.GET("/healthChecker", func(ctx *gin.Context) {
@@ -136,6 +283,12 @@ func main() {
 
 	AuthRouteController.AuthRoute(router)
 	UserRouteController.UserRoute(router)
+	SessionRouteController.SessionRoute(router)
+	MFARouteController.MFARoute(router)
+	WebAuthnRouteController.WebAuthnRoute(router)
+	OAuthRouteController.OAuthRoute(router)
+	APIKeyRouteController.APIKeyRoute(router)
+	AdminRouteController.AdminRoute(router)
 
 	log.Fatal(server.Run(":" + config.Port))
 }
@@ -1,49 +1,119 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"strings"
 
 	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
 	"github.com/AmadoJunior/Gipitty/services"
 	"github.com/AmadoJunior/Gipitty/utils"
 	"github.com/gin-gonic/gin"
 )
 
-func DeserializeUser(userService services.IUserService) gin.HandlerFunc {
+func DeserializeUser(userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		var access_token string
-		cookie, err := ctx.Cookie("access_token")
+		//API Key
+		if key := utils.ExtractAPIKey(ctx); key != "" {
+			apiKey, err := apiKeyService.ValidateAPIKey(key)
+			if err != nil {
+				go utils.LogError(err, ctx)
+				if errors.Is(err, services.ErrInvalidAPIKey) {
+					ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "invalid api key"})
+					return
+				}
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+				return
+			}
 
-		authorizationHeader := ctx.Request.Header.Get("Authorization")
-		fields := strings.Fields(authorizationHeader)
+			if !apiKey.HasScope(requiredAPIKeyScope(ctx.Request.Method)) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "api key lacks the required scope"})
+				return
+			}
 
-		if len(fields) != 0 && fields[0] == "Bearer" {
-			access_token = fields[1]
-		} else if err == nil {
-			access_token = cookie
+			user, err := userService.FindUserById(apiKey.UserID.Hex())
+			if err != nil {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "the user belonging to this api key no longer exists"})
+				return
+			}
+
+			if !user.IsActive() {
+				abortInactiveUser(ctx, user)
+				return
+			}
+
+			ctx.Set("currentUser", user)
+			ctx.Set("apiKey", apiKey)
+			ctx.Next()
+			return
 		}
 
+		access_token := utils.ExtractAccessToken(ctx)
+
 		if access_token == "" {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "you are not logged in"})
 			return
 		}
 
 		config, _ := config.LoadConfig(".")
-		sub, err := utils.ValidateToken(access_token, config.AccessTokenPublicKey)
+		claims, err := authService.ValidateAccessToken(access_token, config)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": err.Error()})
+			go utils.LogError(err, ctx)
+			//Revoked Token
+			if errors.Is(err, services.ErrTokenRevoked) {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "token has been revoked"})
+				return
+			}
+			//Failed to Read Revocation State
+			if errors.Is(err, services.ErrReadingTokenState) {
+				ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"status": "error", "message": "internal server error"})
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "invalid access token"})
 			return
 		}
 
-		user, err := userService.FindUserById(fmt.Sprint(sub))
+		user, err := userService.FindUserById(claims.Subject)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "the user belonging to this token no logger exists"})
 			return
 		}
 
+		if !user.IsActive() {
+			abortInactiveUser(ctx, user)
+			return
+		}
+
 		ctx.Set("currentUser", user)
+		ctx.Set("claims", claims)
+		ctx.Next()
+	}
+}
+
+// Rejects requests authenticated with an API key, for account security
+// settings that should only be changed from a signed in session.
+func RequireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, ok := ctx.Get("apiKey"); ok {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "this action requires signing in"})
+			return
+		}
 		ctx.Next()
 	}
 }
+
+func requiredAPIKeyScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return models.ScopeRead
+	}
+	return models.ScopeWrite
+}
+
+func abortInactiveUser(ctx *gin.Context, user *models.DBResponse) {
+	if user.Status == models.StatusDeleted {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "your account has been deleted"})
+		return
+	}
+	ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "your account has been suspended"})
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type RateLimiter interface {
+	// Records a hit for key and reports whether it is within limit for the
+	// sliding window, and if not, how long until the next hit would be.
+	Allow(key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
+type RateLimitKeyFunc func(ctx *gin.Context) string
+
+func RateLimit(limiter RateLimiter, name string, rule config.RateLimit, keyFuncs ...RateLimitKeyFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, keyFunc := range keyFuncs {
+			key := keyFunc(ctx)
+			if key == "" {
+				continue
+			}
+
+			allowed, retryAfter, err := limiter.Allow(name+":"+key, rule.Limit, rule.Window)
+			if err != nil {
+				//Fail Open
+				continue
+			}
+
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				ctx.Header("Retry-After", strconv.Itoa(seconds))
+				ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"status": "fail", "message": "too many requests, please try again later"})
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+func ByIP(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// Largest body prefix ByEmail reads to find the email.
+const maxEmailKeyBodyBytes = 16 << 10
+
+// Keys by the "email" field of a JSON body, leaving the body readable for the handler.
+func ByEmail(ctx *gin.Context) string {
+	if ctx.Request.Body == nil {
+		return ""
+	}
+
+	//Read a Bounded Prefix, Then Put It Back in Front of the Rest
+	body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxEmailKeyBodyBytes))
+	ctx.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), ctx.Request.Body), ctx.Request.Body}
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Email == "" {
+		return ""
+	}
+
+	return "email:" + strings.ToLower(strings.TrimSpace(payload.Email))
+}
+
+// Keys by the signed in user, so it must run after DeserializeUser.
+func ByUser(ctx *gin.Context) string {
+	value, ok := ctx.Get("currentUser")
+	if !ok {
+		return ""
+	}
+	return "user:" + value.(*models.DBResponse).ID.Hex()
+}
+
+// Uses Redis when available and falls back to an in-process limiter otherwise.
+func NewRateLimiter(ctx context.Context, client *redis.Client) RateLimiter {
+	memory := NewMemoryRateLimiter()
+	if client == nil {
+		return memory
+	}
+	return &fallbackRateLimiter{NewRedisRateLimiter(ctx, client), memory}
+}
+
+type fallbackRateLimiter struct {
+	primary  RateLimiter
+	fallback RateLimiter
+}
+
+func (fl *fallbackRateLimiter) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	allowed, retryAfter, err := fl.primary.Allow(key, limit, window)
+	if err != nil {
+		return fl.fallback.Allow(key, limit, window)
+	}
+	return allowed, retryAfter, nil
+}
+
+// Approximates a sliding window by weighting the previous fixed window's
+// count by how much of it still overlaps the sliding window.
+func slidingWindow(previous int, current int, limit int, elapsed time.Duration, window time.Duration) (bool, time.Duration) {
+	weight := 1 - float64(elapsed)/float64(window)
+	if float64(previous)*weight+float64(current) < float64(limit) {
+		return true, 0
+	}
+
+	//Wait for the Previous Window to Slide Out
+	if current < limit {
+		until := time.Duration(float64(window) * (1 - float64(limit-current)/float64(previous)))
+		return false, until - elapsed + time.Millisecond
+	}
+
+	//Wait for the Current Window to Become the Previous One
+	until := time.Duration(float64(window) * (1 - float64(limit)/float64(current)))
+	return false, window - elapsed + until + time.Millisecond
+}
+
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local weight = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+if previous * weight + current < limit then
+	current = redis.call("INCR", KEYS[1])
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+	return {1, current, previous}
+end
+return {0, current, previous}
+`)
+
+type RedisRateLimiter struct {
+	ctx    context.Context
+	client *redis.Client
+}
+
+func NewRedisRateLimiter(ctx context.Context, client *redis.Client) *RedisRateLimiter {
+	return &RedisRateLimiter{ctx, client}
+}
+
+func (rl *RedisRateLimiter) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	now := time.Now().UnixMilli()
+	size := window.Milliseconds()
+	index := now / size
+	elapsed := time.Duration(now-index*size) * time.Millisecond
+
+	keys := []string{
+		"rate_limit:" + key + ":" + strconv.FormatInt(index, 10),
+		"rate_limit:" + key + ":" + strconv.FormatInt(index-1, 10),
+	}
+	weight := 1 - float64(elapsed)/float64(window)
+
+	result, err := slidingWindowScript.Run(rl.ctx, rl.client, keys, weight, limit, 2*size).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+
+	if result[0] == 1 {
+		return true, 0, nil
+	}
+
+	_, retryAfter := slidingWindow(int(result[2]), int(result[1]), limit, elapsed, window)
+	return false, retryAfter, nil
+}
+
+type windowCounter struct {
+	index    int64
+	size     time.Duration
+	current  int
+	previous int
+}
+
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	counters  map[string]*windowCounter
+	lastPrune time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{counters: make(map[string]*windowCounter), lastPrune: time.Now()}
+}
+
+func (ml *MemoryRateLimiter) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	ml.mu.Lock()
+	defer ml.mu.Unlock()
+
+	now := time.Now()
+	index := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(window))
+
+	counter, ok := ml.counters[key]
+	if !ok {
+		ml.prune(now)
+		counter = &windowCounter{index: index, size: window}
+		ml.counters[key] = counter
+	}
+
+	//Slide Windows
+	switch {
+	case counter.index == index-1:
+		counter.previous, counter.current = counter.current, 0
+	case counter.index < index-1:
+		counter.previous, counter.current = 0, 0
+	}
+	counter.index = index
+
+	allowed, retryAfter := slidingWindow(counter.previous, counter.current, limit, elapsed, window)
+	if allowed {
+		counter.current++
+	}
+
+	return allowed, retryAfter, nil
+}
+
+// Drops counters that no longer affect any sliding window, at most once a minute.
+func (ml *MemoryRateLimiter) prune(now time.Time) {
+	if now.Sub(ml.lastPrune) < time.Minute {
+		return
+	}
+	ml.lastPrune = now
+
+	for key, counter := range ml.counters {
+		if now.UnixNano()/int64(counter.size) > counter.index+1 {
+			delete(ml.counters, key)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	window := time.Minute
+	tests := []struct {
+		name       string
+		previous   int
+		current    int
+		limit      int
+		elapsed    time.Duration
+		allowed    bool
+		retryAfter time.Duration
+	}{
+		{"empty windows", 0, 0, 5, 0, true, 0},
+		{"under limit", 0, 4, 5, 30 * time.Second, true, 0},
+		{"current window full", 0, 5, 5, 30 * time.Second, false, 30*time.Second + time.Millisecond},
+		{"previous window still weighs in", 10, 0, 5, 15 * time.Second, false, 15*time.Second + time.Millisecond},
+		{"previous window slid out enough", 10, 0, 5, 31 * time.Second, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, retryAfter := slidingWindow(tt.previous, tt.current, tt.limit, tt.elapsed, window)
+			if allowed != tt.allowed {
+				t.Fatalf("allowed = %v, want %v", allowed, tt.allowed)
+			}
+			if retryAfter != tt.retryAfter {
+				t.Fatalf("retryAfter = %v, want %v", retryAfter, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	limiter := NewMemoryRateLimiter()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow("login:ip:1.2.3.4", 3, time.Hour)
+		if err != nil || !allowed {
+			t.Fatalf("hit %d: allowed = %v, err = %v", i+1, allowed, err)
+		}
+	}
+
+	allowed, retryAfter, err := limiter.Allow("login:ip:1.2.3.4", 3, time.Hour)
+	if err != nil || allowed {
+		t.Fatalf("hit over limit: allowed = %v, err = %v", allowed, err)
+	}
+	if retryAfter <= 0 || retryAfter > 2*time.Hour {
+		t.Fatalf("retryAfter = %v, want within two windows", retryAfter)
+	}
+
+	//Other Keys Are Counted Separately
+	if allowed, _, _ := limiter.Allow("login:ip:5.6.7.8", 3, time.Hour); !allowed {
+		t.Fatal("other key was limited")
+	}
+}
+
+func TestRateLimitSetsRetryAfter(t *testing.T) {
+	router := gin.New()
+	router.POST("/login", RateLimit(NewMemoryRateLimiter(), "login", config.RateLimit{Limit: 1, Window: time.Hour}, ByIP), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/login", nil))
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", first.Code, http.StatusOK)
+	}
+
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest(http.MethodPost, "/login", nil))
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", second.Code, http.StatusTooManyRequests)
+	}
+
+	seconds, err := strconv.Atoi(second.Header().Get("Retry-After"))
+	if err != nil || seconds < 1 {
+		t.Fatalf("Retry-After = %q, want a positive number of seconds", second.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimitByEmail(t *testing.T) {
+	var handlerBody string
+	router := gin.New()
+	router.POST("/forgotpassword", RateLimit(NewMemoryRateLimiter(), "forgotpassword", config.RateLimit{Limit: 1, Window: time.Hour}, ByEmail), func(ctx *gin.Context) {
+		body, _ := io.ReadAll(ctx.Request.Body)
+		handlerBody = string(body)
+		ctx.Status(http.StatusOK)
+	})
+
+	send := func(body string) int {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/forgotpassword", strings.NewReader(body)))
+		return recorder.Code
+	}
+
+	body := `{"email":"Jane@Example.com"}`
+	if code := send(body); code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", code, http.StatusOK)
+	}
+	if handlerBody != body {
+		t.Fatalf("handler read %q, want %q", handlerBody, body)
+	}
+
+	//Same Address, Different Case
+	if code := send(`{"email":"jane@example.com"}`); code != http.StatusTooManyRequests {
+		t.Fatalf("repeat request status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestByEmailBoundsBodyRead(t *testing.T) {
+	body := `{"padding":"` + strings.Repeat("a", 2*maxEmailKeyBodyBytes) + `","email":"jane@example.com"}`
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	if key := ByEmail(ctx); key != "" {
+		t.Fatalf("key = %q, want none for an oversized body", key)
+	}
+
+	//The Handler Still Sees the Whole Body
+	rest, _ := io.ReadAll(ctx.Request.Body)
+	if string(rest) != body {
+		t.Fatalf("body was not restored, read %d of %d bytes", len(rest), len(body))
+	}
+}
+
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Allow(key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	return false, 0, errors.New("unavailable")
+}
+
+func TestFallbackRateLimiter(t *testing.T) {
+	limiter := &fallbackRateLimiter{failingRateLimiter{}, NewMemoryRateLimiter()}
+
+	if allowed, _, err := limiter.Allow("key", 1, time.Hour); err != nil || !allowed {
+		t.Fatalf("first hit: allowed = %v, err = %v", allowed, err)
+	}
+	if allowed, _, err := limiter.Allow("key", 1, time.Hour); err != nil || allowed {
+		t.Fatalf("second hit: allowed = %v, err = %v, want limited by the fallback", allowed, err)
+	}
+}
+
+func TestNewRateLimiterFallsBackWithoutRedis(t *testing.T) {
+	//Nothing Listens on Port 1
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer client.Close()
+
+	limiter := NewRateLimiter(context.Background(), client)
+	if allowed, _, err := limiter.Allow("key", 1, time.Hour); err != nil || !allowed {
+		t.Fatalf("first hit: allowed = %v, err = %v", allowed, err)
+	}
+	if allowed, _, err := limiter.Allow("key", 1, time.Hour); err != nil || allowed {
+		t.Fatalf("second hit: allowed = %v, err = %v, want limited in process", allowed, err)
+	}
+}
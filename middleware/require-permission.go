@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Rejects requests whose user's role lacks any of the given permissions. The
+// role is read from the user loaded by DeserializeUser rather than the token
+// claims, so a demotion takes effect immediately.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		value, ok := ctx.Get("currentUser")
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"status": "fail", "message": "you are not logged in"})
+			return
+		}
+
+		currentUser := value.(*models.DBResponse)
+		for _, permission := range permissions {
+			if !models.HasPermission(currentUser.Role, permission) {
+				ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"status": "fail", "message": "you do not have permission to perform this action"})
+				return
+			}
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// Scopes serialized as the space delimited "scope" string of RFC 9068.
+type ScopeList []string
+
+func (sl ScopeList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.Join(sl, " "))
+}
+
+func (sl *ScopeList) UnmarshalJSON(data []byte) error {
+	var scope string
+	if err := json.Unmarshal(data, &scope); err != nil {
+		return err
+	}
+	*sl = strings.Fields(scope)
+	return nil
+}
+
+func (sl ScopeList) Has(scope string) bool {
+	for _, s := range sl {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type TokenClaims struct {
+	jwt.RegisteredClaims
+	Role       string    `json:"role,omitempty"`
+	Scope      ScopeList `json:"scope,omitempty"`
+	Family     string    `json:"fam,omitempty"`
+	Generation int64     `json:"gen"`
+}
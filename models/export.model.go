@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A generated data export archive, downloadable until it expires.
+type DataExport struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Token     string             `bson:"token"`
+	Archive   []byte             `bson:"archive"`
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+}
+
+// Everything stored about a user. Password hashes, second factor secrets
+// and one-time tokens are deliberately left out.
+type UserDataExport struct {
+	GeneratedAt time.Time             `json:"generated_at"`
+	Profile     ExportedProfile       `json:"profile"`
+	Identities  []LinkedIdentity      `json:"linked_accounts"`
+	Sessions    []*Session            `json:"sessions"`
+	Credentials []*WebAuthnCredential `json:"passkeys"`
+	APIKeys     []*APIKey             `json:"api_keys"`
+}
+
+type ExportedProfile struct {
+	ID                primitive.ObjectID `json:"id"`
+	Name              string             `json:"name"`
+	Email             string             `json:"email"`
+	Role              string             `json:"role"`
+	Status            string             `json:"status,omitempty"`
+	Verified          bool               `json:"verified"`
+	TOTPEnabled       bool               `json:"two_factor_enabled"`
+	LastFailedLoginAt time.Time          `json:"last_failed_login_at,omitempty"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+}
+
+func ExportProfile(user *DBResponse) ExportedProfile {
+	return ExportedProfile{
+		ID:                user.ID,
+		Name:              user.Name,
+		Email:             user.Email,
+		Role:              user.Role,
+		Status:            user.Status,
+		Verified:          user.Verified,
+		TOTPEnabled:       user.TOTPEnabled,
+		LastFailedLoginAt: user.LastFailedLoginAt,
+		CreatedAt:         user.CreatedAt,
+		UpdatedAt:         user.UpdatedAt,
+	}
+}
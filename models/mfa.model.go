@@ -0,0 +1,22 @@
+package models
+
+type MFACodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type MFALoginInput struct {
+	//Falls Back to the mfa_token Cookie When Empty
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// Either the account password or a current TOTP or recovery code.
+type MFADisableInput struct {
+	Password string `json:"password" binding:"required_without=Code"`
+	Code     string `json:"code" binding:"required_without=Password"`
+}
+
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
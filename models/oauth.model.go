@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// An external account linked to a user, keyed by provider and the provider's subject.
+type LinkedIdentity struct {
+	Provider string    `json:"provider" bson:"provider"`
+	Subject  string    `json:"-" bson:"subject"`
+	Email    string    `json:"email" bson:"email"`
+	LinkedAt time.Time `json:"linked_at" bson:"linked_at"`
+}
+
+// The identity asserted by a provider after a successful code exchange.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Stored server side between redirecting to a provider and its callback.
+type OAuthState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
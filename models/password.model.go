@@ -0,0 +1,6 @@
+package models
+
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,42 @@
+package models
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionReadUsers   Permission = "users:read"
+	PermissionManageUsers Permission = "users:manage"
+	PermissionManageRoles Permission = "roles:manage"
+)
+
+// Named permissions granted to each role. Roles not listed grant nothing.
+var RolePermissions = map[string][]Permission{
+	RoleUser: {},
+	RoleAdmin: {
+		PermissionReadUsers,
+		PermissionManageUsers,
+		PermissionManageRoles,
+	},
+}
+
+type UpdateRoleInput struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func IsRole(role string) bool {
+	_, ok := RolePermissions[role]
+	return ok
+}
+
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
+
+type AuthTokens struct {
+	AccessToken  string
+	RefreshToken string
+	MFAToken     string
+}
+
+type Session struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Family     string             `json:"-" bson:"family"`
+	UserAgent  string             `json:"user_agent" bson:"user_agent"`
+	IP         string             `json:"ip" bson:"ip"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	LastUsedAt time.Time          `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// A token signing key shared between instances. Ring names the token type
+// the key signs and PrivateKey holds the private key, encrypted with the
+// configured key encryption key unless it predates encryption.
+type SigningKey struct {
+	KeyID       string    `bson:"kid"`
+	Ring        string    `bson:"ring"`
+	Algorithm   string    `bson:"alg"`
+	PrivateKey  string    `bson:"private_key"`
+	Encrypted   bool      `bson:"encrypted,omitempty"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ActivatesAt time.Time `bson:"activates_at"`
+	ExpiresAt   time.Time `bson:"expires_at,omitempty"`
+}
@@ -6,10 +6,18 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type ResendVerificationInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
 type ForgotPasswordInput struct {
 	Email string `json:"email" binding:"required"`
 }
 
+type MagicLinkInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
 type ResetPasswordInput struct {
 	Password        string `json:"password" binding:"required"`
 	PasswordConfirm string `json:"passwordConfirm" binding:"required"`
@@ -18,9 +26,10 @@ type ResetPasswordInput struct {
 type SignUpInput struct {
 	Name            string    `json:"name" bson:"name" binding:"required"`
 	Email           string    `json:"email" bson:"email" binding:"required"`
-	Password        string    `json:"password" bson:"password" binding:"required,min=8"`
+	Password        string    `json:"password" bson:"password" binding:"required"`
 	PasswordConfirm string    `json:"passwordConfirm" bson:"passwordConfirm,omitempty" binding:"required"`
 	Role            string    `json:"role" bson:"role"`
+	Status          string    `json:"status" bson:"status"`
 	Verified        bool      `json:"verified" bson:"verified"`
 	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" bson:"updated_at"`
@@ -31,6 +40,16 @@ type SignInInput struct {
 	Password string `json:"password" bson:"password" binding:"required"`
 }
 
+// The profile fields a user may change about themselves. Privileged fields
+// such as role, status and verification are deliberately absent.
+type UpdateProfileInput struct {
+	Name      *string `json:"name" bson:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	AvatarURL *string `json:"avatarUrl" bson:"avatarUrl,omitempty" binding:"omitempty,http_url,max=2048"`
+	Timezone  *string `json:"timezone" bson:"timezone,omitempty" binding:"omitempty,timezone"`
+	Locale    *string `json:"locale" bson:"locale,omitempty" binding:"omitempty,bcp47_language_tag"`
+	Theme     *string `json:"theme" bson:"theme,omitempty" binding:"omitempty,oneof=system light dark"`
+}
+
 type UpdateInput struct {
 	Name               string    `json:"name,omitempty" bson:"name,omitempty"`
 	Email              string    `json:"email,omitempty" bson:"email,omitempty"`
@@ -54,6 +73,44 @@ type DBResponse struct {
 	Verified        bool               `json:"verified" bson:"verified"`
 	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+
+	AvatarURL string `json:"avatarUrl,omitempty" bson:"avatarUrl,omitempty"`
+	Timezone  string `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Locale    string `json:"locale,omitempty" bson:"locale,omitempty"`
+	Theme     string `json:"theme,omitempty" bson:"theme,omitempty"`
+
+	PendingEmail         string    `json:"pendingEmail,omitempty" bson:"pendingEmail,omitempty"`
+	EmailChangeExpiresAt time.Time `json:"-" bson:"emailChangeExpiresAt,omitempty"`
+
+	FailedLoginAttempts int       `json:"failedLoginAttempts,omitempty" bson:"failedLoginAttempts,omitempty"`
+	LastFailedLoginAt   time.Time `json:"lastFailedLoginAt,omitempty" bson:"lastFailedLoginAt,omitempty"`
+	LockedUntil         time.Time `json:"lockedUntil,omitempty" bson:"lockedUntil,omitempty"`
+
+	TOTPEnabled       bool     `json:"totpEnabled" bson:"totpEnabled,omitempty"`
+	TOTPSecret        string   `json:"-" bson:"totpSecret,omitempty"`
+	TOTPPendingSecret string   `json:"-" bson:"totpPendingSecret,omitempty"`
+	TOTPLastUsedStep  int64    `json:"-" bson:"totpLastUsedStep,omitempty"`
+	RecoveryCodes     []string `json:"-" bson:"recoveryCodes,omitempty"`
+
+	Identities []LinkedIdentity `json:"identities,omitempty" bson:"identities,omitempty"`
+
+	Status          string    `json:"status" bson:"status,omitempty"`
+	StatusReason    string    `json:"statusReason,omitempty" bson:"statusReason,omitempty"`
+	StatusChangedAt time.Time `json:"statusChangedAt,omitempty" bson:"statusChangedAt,omitempty"`
+	PurgeAt         time.Time `json:"purgeAt,omitempty" bson:"purgeAt,omitempty"`
+}
+
+// Failed sign-ins for an email without an account, tracked like an account's
+// own so that a lockout does not reveal whether the account exists.
+type UnknownSignIns struct {
+	Attempts     int
+	LastFailedAt time.Time
+	LockedUntil  time.Time
+}
+
+// Accounts created before statuses existed have none and are active.
+func (user *DBResponse) IsActive() bool {
+	return user.Status == "" || user.Status == StatusActive
 }
 
 type UserResponse struct {
@@ -61,17 +118,74 @@ type UserResponse struct {
 	Name      string             `json:"name,omitempty" bson:"name,omitempty"`
 	Email     string             `json:"email,omitempty" bson:"email,omitempty"`
 	Role      string             `json:"role,omitempty" bson:"role,omitempty"`
+	Verified  bool               `json:"verified" bson:"verified"`
+	Status    string             `json:"status,omitempty" bson:"status,omitempty"`
+	AvatarURL string             `json:"avatarUrl,omitempty" bson:"avatarUrl,omitempty"`
+	Timezone  string             `json:"timezone,omitempty" bson:"timezone,omitempty"`
+	Locale    string             `json:"locale,omitempty" bson:"locale,omitempty"`
+	Theme     string             `json:"theme,omitempty" bson:"theme,omitempty"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusDeleted   = "deleted"
+)
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	Password        string `json:"password" binding:"required"`
+	PasswordConfirm string `json:"passwordConfirm" binding:"required"`
+}
+
+type ChangeEmailInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type SuspendUserInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Filters and pagination for listing users. Search matches name or email.
+type UserQuery struct {
+	Page          int       `form:"page" binding:"omitempty,min=1"`
+	Limit         int       `form:"limit" binding:"omitempty,min=1,max=100"`
+	Verified      *bool     `form:"verified"`
+	Role          string    `form:"role"`
+	Status        string    `form:"status"`
+	CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02T15:04:05Z07:00"`
+	Search        string    `form:"q"`
+}
+
 func FilteredResponse(user *DBResponse) UserResponse {
 	return UserResponse{
 		ID:        user.ID,
 		Email:     user.Email,
 		Name:      user.Name,
 		Role:      user.Role,
+		Verified:  user.Verified,
+		Status:    user.Status,
+		AvatarURL: user.AvatarURL,
+		Timezone:  user.Timezone,
+		Locale:    user.Locale,
+		Theme:     user.Theme,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
 }
+
+func FilteredResponses(users []*DBResponse) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, FilteredResponse(user))
+	}
+	return responses
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type WebAuthnCredential struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID       primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Name         string              `json:"name" bson:"name"`
+	CredentialID string              `json:"-" bson:"credential_id"`
+	Credential   webauthn.Credential `json:"-" bson:"credential"`
+	CreatedAt    time.Time           `json:"created_at" bson:"created_at"`
+	LastUsedAt   time.Time           `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IAPIKeyRepo interface {
+	//Core
+	InitRepository(client *mongo.Client, dbName string, repoName string) error
+
+	//Public
+	CreateAPIKey(apiKey *models.APIKey) (string, error)
+	FindAPIKeysByUserID(userID string) ([]*models.APIKey, error)
+	FindAPIKeyByHash(hash string) (*models.APIKey, error)
+	TouchAPIKey(id primitive.ObjectID, lastUsedAt time.Time) error
+	DeleteAPIKey(id string, userID string) error
+	DeleteAPIKeysByUserID(userID string) error
+}
@@ -0,0 +1,132 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APIKeyRepoImpl struct {
+	ctx    context.Context
+	client *mongo.Client
+	store  *mongo.Collection
+}
+
+func NewAPIKeyRepo(ctx context.Context) *APIKeyRepoImpl {
+	return &APIKeyRepoImpl{ctx: ctx}
+}
+
+func (ar *APIKeyRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
+	ar.client = client
+	ar.store = ar.client.Database(dbName).Collection(repoName)
+
+	_, err := ar.store.Indexes().CreateMany(ar.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return utils.GenerateError(ErrAPIKeyRepoInit, err)
+	}
+
+	return nil
+}
+
+func (ar APIKeyRepoImpl) CreateAPIKey(apiKey *models.APIKey) (string, error) {
+	insertResult, err := ar.store.InsertOne(ar.ctx, apiKey)
+	if err != nil {
+		return "", utils.GenerateError(ErrAPIKeyInsertion, err)
+	}
+
+	// Assert InsertedID to ObjectID
+	idObj, isObjID := insertResult.InsertedID.(primitive.ObjectID)
+	if !isObjID {
+		return "", ErrAPIKeyIDAssertion
+	}
+
+	return idObj.Hex(), nil
+}
+
+func (ar APIKeyRepoImpl) FindAPIKeysByUserID(userID string) ([]*models.APIKey, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := ar.store.Find(ar.ctx, bson.M{"user_id": objID}, opts)
+	if err != nil {
+		return nil, utils.GenerateError(ErrAPIKeyNotFound, err)
+	}
+
+	apiKeys := []*models.APIKey{}
+	if err := cursor.All(ar.ctx, &apiKeys); err != nil {
+		return nil, utils.GenerateError(ErrAPIKeyNotFound, err)
+	}
+
+	return apiKeys, nil
+}
+
+func (ar APIKeyRepoImpl) FindAPIKeyByHash(hash string) (*models.APIKey, error) {
+	apiKey := &models.APIKey{}
+	err := ar.store.FindOne(ar.ctx, bson.M{"hash": hash}).Decode(apiKey)
+
+	if err != nil {
+		return nil, utils.GenerateError(ErrAPIKeyNotFound, err)
+	}
+
+	return apiKey, nil
+}
+
+func (ar APIKeyRepoImpl) TouchAPIKey(id primitive.ObjectID, lastUsedAt time.Time) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: lastUsedAt}}}}
+	_, err := ar.store.UpdateOne(ar.ctx, bson.M{"_id": id}, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrAPIKeyUpdate, err)
+	}
+
+	return nil
+}
+
+func (ar APIKeyRepoImpl) DeleteAPIKey(id string, userID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	res, err := ar.store.DeleteOne(ar.ctx, bson.M{"_id": objID, "user_id": userObjID})
+	if err != nil {
+		return utils.GenerateError(ErrAPIKeyDelete, err)
+	}
+
+	if res.DeletedCount < 1 {
+		return utils.GenerateError(ErrAPIKeyNotFound, err)
+	}
+
+	return nil
+}
+
+func (ar APIKeyRepoImpl) DeleteAPIKeysByUserID(userID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	_, err = ar.store.DeleteMany(ar.ctx, bson.M{"user_id": userObjID})
+	if err != nil {
+		return utils.GenerateError(ErrAPIKeyDelete, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,20 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ICredentialRepo interface {
+	//Core
+	InitRepository(client *mongo.Client, dbName string, repoName string) error
+
+	//Public
+	CreateCredential(credential *models.WebAuthnCredential) (string, error)
+	FindCredentialsByUserID(userID string) ([]*models.WebAuthnCredential, error)
+	UpdateCredentialUsage(credentialID string, signCount uint32, cloneWarning bool, lastUsedAt time.Time) error
+	DeleteCredential(id string, userID string) error
+	DeleteCredentialsByUserID(userID string) error
+}
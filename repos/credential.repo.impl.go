@@ -0,0 +1,132 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CredentialRepoImpl struct {
+	ctx    context.Context
+	client *mongo.Client
+	store  *mongo.Collection
+}
+
+func NewCredentialRepo(ctx context.Context) *CredentialRepoImpl {
+	return &CredentialRepoImpl{ctx: ctx}
+}
+
+func (cr *CredentialRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
+	cr.client = client
+	cr.store = cr.client.Database(dbName).Collection(repoName)
+
+	_, err := cr.store.Indexes().CreateMany(cr.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "credential_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return utils.GenerateError(ErrCredentialRepoInit, err)
+	}
+
+	return nil
+}
+
+func (cr CredentialRepoImpl) CreateCredential(credential *models.WebAuthnCredential) (string, error) {
+	insertResult, err := cr.store.InsertOne(cr.ctx, credential)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", utils.GenerateError(ErrDuplicateCredential, err)
+		}
+		return "", utils.GenerateError(ErrCredentialInsertion, err)
+	}
+
+	// Assert InsertedID to ObjectID
+	idObj, isObjID := insertResult.InsertedID.(primitive.ObjectID)
+	if !isObjID {
+		return "", ErrCredentialIDAssertion
+	}
+
+	return idObj.Hex(), nil
+}
+
+func (cr CredentialRepoImpl) FindCredentialsByUserID(userID string) ([]*models.WebAuthnCredential, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	cursor, err := cr.store.Find(cr.ctx, bson.M{"user_id": objID})
+	if err != nil {
+		return nil, utils.GenerateError(ErrCredentialNotFound, err)
+	}
+
+	credentials := []*models.WebAuthnCredential{}
+	if err := cursor.All(cr.ctx, &credentials); err != nil {
+		return nil, utils.GenerateError(ErrCredentialNotFound, err)
+	}
+
+	return credentials, nil
+}
+
+func (cr CredentialRepoImpl) UpdateCredentialUsage(credentialID string, signCount uint32, cloneWarning bool, lastUsedAt time.Time) error {
+	query := bson.D{{Key: "credential_id", Value: credentialID}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "credential.authenticator.signcount", Value: signCount},
+		{Key: "credential.authenticator.clonewarning", Value: cloneWarning},
+		{Key: "last_used_at", Value: lastUsedAt},
+	}}}
+	res, err := cr.store.UpdateOne(cr.ctx, query, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrCredentialUpdate, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrCredentialNotFound, err)
+	}
+
+	return nil
+}
+
+func (cr CredentialRepoImpl) DeleteCredential(id string, userID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	res, err := cr.store.DeleteOne(cr.ctx, bson.M{"_id": objID, "user_id": userObjID})
+	if err != nil {
+		return utils.GenerateError(ErrCredentialDelete, err)
+	}
+
+	if res.DeletedCount < 1 {
+		return utils.GenerateError(ErrCredentialNotFound, err)
+	}
+
+	return nil
+}
+
+func (cr CredentialRepoImpl) DeleteCredentialsByUserID(userID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	_, err = cr.store.DeleteMany(cr.ctx, bson.M{"user_id": userObjID})
+	if err != nil {
+		return utils.GenerateError(ErrCredentialDelete, err)
+	}
+
+	return nil
+}
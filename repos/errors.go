@@ -3,16 +3,68 @@ package repos
 import "errors"
 
 var (
-	ErrUserRepoInit            = errors.New("failed to initiate user repository")
-	ErrUserRepoDeinit          = errors.New("failed to deinitiate user repository")
-	ErrDuplicateEmail          = errors.New("user email already in use")
-	ErrUserInsertion           = errors.New("failed to insert user")
-	ErrUserIDAssertion         = errors.New("failed to assert user object id")
-	ErrInvalidIDHex            = errors.New("failed to create object id from hex")
-	ErrUserNotFound            = errors.New("failed to find user")
-	ErrUserUpdate              = errors.New("failed to update user")
-	ErrUserVerification        = errors.New("failed to verify user")
-	ErrStorePasswordResetToken = errors.New("failed to store password reset token")
-	ErrResetPassword           = errors.New("failed to reset password")
-	ErrInvalidUpdateInput      = errors.New("provided update input is invalid")
+	ErrUserRepoInit             = errors.New("failed to initiate user repository")
+	ErrUserRepoDeinit           = errors.New("failed to deinitiate user repository")
+	ErrDuplicateEmail           = errors.New("user email already in use")
+	ErrUserInsertion            = errors.New("failed to insert user")
+	ErrUserIDAssertion          = errors.New("failed to assert user object id")
+	ErrInvalidIDHex             = errors.New("failed to create object id from hex")
+	ErrUserNotFound             = errors.New("failed to find user")
+	ErrUserUpdate               = errors.New("failed to update user")
+	ErrUserDelete               = errors.New("failed to delete user")
+	ErrUserQuery                = errors.New("failed to query users")
+	ErrUserVerification         = errors.New("failed to verify user")
+	ErrStorePasswordResetToken  = errors.New("failed to store password reset token")
+	ErrResetPassword            = errors.New("failed to reset password")
+	ErrInvalidUpdateInput       = errors.New("provided update input is invalid")
+	ErrTokenRepoDeinit          = errors.New("failed to deinitiate token repository")
+	ErrStoreRefreshToken        = errors.New("failed to store refresh token")
+	ErrRevokeRefreshToken       = errors.New("failed to revoke refresh token family")
+	ErrRefreshFamilyNotFound    = errors.New("refresh token family not found")
+	ErrRefreshTokenReused       = errors.New("refresh token reuse detected")
+	ErrDenylistToken            = errors.New("failed to denylist token")
+	ErrReadTokenState           = errors.New("failed to read token state")
+	ErrIncrementTokenGeneration = errors.New("failed to increment token generation")
+	ErrSessionRepoInit          = errors.New("failed to initiate session repository")
+	ErrSessionInsertion         = errors.New("failed to insert session")
+	ErrSessionIDAssertion       = errors.New("failed to assert session object id")
+	ErrSessionNotFound          = errors.New("failed to find session")
+	ErrSessionUpdate            = errors.New("failed to update session")
+	ErrSessionDelete            = errors.New("failed to delete session")
+	ErrStoreMFAChallenge        = errors.New("failed to store mfa challenge")
+	ErrMFAChallengeNotFound     = errors.New("mfa challenge not found")
+	ErrCredentialRepoInit       = errors.New("failed to initiate credential repository")
+	ErrCredentialInsertion      = errors.New("failed to insert credential")
+	ErrDuplicateCredential      = errors.New("credential already registered")
+	ErrCredentialIDAssertion    = errors.New("failed to assert credential object id")
+	ErrCredentialNotFound       = errors.New("failed to find credential")
+	ErrCredentialUpdate         = errors.New("failed to update credential")
+	ErrCredentialDelete         = errors.New("failed to delete credential")
+	ErrWebAuthnSessionNotFound  = errors.New("webauthn session not found")
+	ErrStoreWebAuthnSession     = errors.New("failed to store webauthn session")
+	ErrOAuthStateNotFound       = errors.New("oauth state not found")
+	ErrStoreOAuthState          = errors.New("failed to store oauth state")
+	ErrStoreUnknownSignIn       = errors.New("failed to store unknown sign-in")
+	ErrLinkIdentity             = errors.New("failed to link identity")
+	ErrDuplicateIdentity        = errors.New("identity provider already linked")
+	ErrStoreMagicLinkToken      = errors.New("failed to store magic link token")
+	ErrAPIKeyRepoInit           = errors.New("failed to initiate api key repository")
+	ErrAPIKeyInsertion          = errors.New("failed to insert api key")
+	ErrAPIKeyIDAssertion        = errors.New("failed to assert api key object id")
+	ErrAPIKeyNotFound           = errors.New("failed to find api key")
+	ErrAPIKeyUpdate             = errors.New("failed to update api key")
+	ErrAPIKeyDelete             = errors.New("failed to delete api key")
+	ErrSigningKeyRepoInit       = errors.New("failed to initiate signing key repository")
+	ErrSigningKeyInsertion      = errors.New("failed to insert signing key")
+	ErrDuplicateSigningKey      = errors.New("signing key already exists")
+	ErrSigningKeyNotFound       = errors.New("failed to find signing keys")
+	ErrSigningKeyUpdate         = errors.New("failed to update signing keys")
+	ErrExportRepoInit           = errors.New("failed to initiate export repository")
+	ErrExportInsertion          = errors.New("failed to insert export")
+	ErrExportIDAssertion        = errors.New("failed to assert export object id")
+	ErrExportNotFound           = errors.New("failed to find export")
+	ErrExportDelete             = errors.New("failed to delete exports")
+	ErrTokenMigration           = errors.New("failed to migrate one-time tokens")
+	ErrMigrationQuery           = errors.New("failed to read applied migrations")
+	ErrMigrationUpdate          = errors.New("failed to record applied migration")
 )
@@ -0,0 +1,17 @@
+package repos
+
+import (
+	"github.com/AmadoJunior/Gipitty/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IExportRepo interface {
+	//Core
+	InitRepository(client *mongo.Client, dbName string, repoName string) error
+
+	//Public
+	CreateExport(export *models.DataExport) (string, error)
+	FindExportByToken(token string, userID string) (*models.DataExport, error)
+	DeleteExportsByUserID(userID string) error
+	MigrateTokenDigests(convert func(string) (string, error)) (int64, error)
+}
@@ -0,0 +1,92 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ExportRepoImpl struct {
+	ctx    context.Context
+	client *mongo.Client
+	store  *mongo.Collection
+}
+
+func NewExportRepo(ctx context.Context) *ExportRepoImpl {
+	return &ExportRepoImpl{ctx: ctx}
+}
+
+func (er *ExportRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
+	er.client = client
+	er.store = er.client.Database(dbName).Collection(repoName)
+
+	//Indexes, Expired Exports Are Removed by Mongo
+	_, err := er.store.Indexes().CreateMany(er.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return utils.GenerateError(ErrExportRepoInit, err)
+	}
+
+	return nil
+}
+
+func (er ExportRepoImpl) CreateExport(export *models.DataExport) (string, error) {
+	insertResult, err := er.store.InsertOne(er.ctx, export)
+	if err != nil {
+		return "", utils.GenerateError(ErrExportInsertion, err)
+	}
+
+	// Assert InsertedID to ObjectID
+	idObj, isObjID := insertResult.InsertedID.(primitive.ObjectID)
+	if !isObjID {
+		return "", ErrExportIDAssertion
+	}
+
+	return idObj.Hex(), nil
+}
+
+// Finds an unexpired export by its download token. Mongo's TTL monitor only
+// runs once a minute, so expiry is checked here as well.
+func (er ExportRepoImpl) FindExportByToken(token string, userID string) (*models.DataExport, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	export := &models.DataExport{}
+	filter := bson.M{"token": token, "user_id": userObjID, "expires_at": bson.M{"$gt": time.Now()}}
+	err = er.store.FindOne(er.ctx, filter).Decode(export)
+
+	if err != nil {
+		return nil, utils.GenerateError(ErrExportNotFound, err)
+	}
+
+	return export, nil
+}
+
+func (er ExportRepoImpl) DeleteExportsByUserID(userID string) error {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	_, err = er.store.DeleteMany(er.ctx, bson.M{"user_id": userObjID})
+	if err != nil {
+		return utils.GenerateError(ErrExportDelete, err)
+	}
+
+	return nil
+}
+
+func (er ExportRepoImpl) MigrateTokenDigests(convert func(string) (string, error)) (int64, error) {
+	return migrateTokenDigests(er.ctx, er.store, []string{"token"}, convert)
+}
@@ -0,0 +1,14 @@
+package repos
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IMigrationRepo interface {
+	//Core
+	InitRepository(client *mongo.Client, dbName string, repoName string) error
+
+	//Public
+	IsApplied(name string) (bool, error)
+	MarkApplied(name string) error
+}
@@ -0,0 +1,53 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Records one-off data migrations by name, so they run once rather than on
+// every start.
+type MigrationRepoImpl struct {
+	ctx    context.Context
+	client *mongo.Client
+	store  *mongo.Collection
+}
+
+func NewMigrationRepo(ctx context.Context) *MigrationRepoImpl {
+	return &MigrationRepoImpl{ctx: ctx}
+}
+
+func (mr *MigrationRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
+	mr.client = client
+	mr.store = mr.client.Database(dbName).Collection(repoName)
+	return nil
+}
+
+func (mr MigrationRepoImpl) IsApplied(name string) (bool, error) {
+	err := mr.store.FindOne(mr.ctx, bson.M{"_id": name}).Err()
+
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, utils.GenerateError(ErrMigrationQuery, err)
+	}
+
+	return true, nil
+}
+
+func (mr MigrationRepoImpl) MarkApplied(name string) error {
+	update := bson.M{"$setOnInsert": bson.M{"applied_at": time.Now()}}
+	_, err := mr.store.UpdateOne(mr.ctx, bson.M{"_id": name}, update, options.Update().SetUpsert(true))
+
+	if err != nil {
+		return utils.GenerateError(ErrMigrationUpdate, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ISessionRepo interface {
+	//Core
+	InitRepository(client *mongo.Client, dbName string, repoName string) error
+
+	//Public
+	CreateSession(session *models.Session) (string, error)
+	FindSessionsByUserID(userID string) ([]*models.Session, error)
+	TouchSession(family string, lastUsedAt time.Time, expiresAt time.Time) error
+	DeleteSessionByID(id string, userID string) (*models.Session, error)
+	DeleteSessionByFamily(family string) error
+	DeleteSessionsByUserID(userID string) ([]*models.Session, error)
+}
@@ -0,0 +1,137 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SessionRepoImpl struct {
+	ctx    context.Context
+	client *mongo.Client
+	store  *mongo.Collection
+}
+
+func NewSessionRepo(ctx context.Context) *SessionRepoImpl {
+	return &SessionRepoImpl{ctx: ctx}
+}
+
+func (sr *SessionRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
+	sr.client = client
+	sr.store = sr.client.Database(dbName).Collection(repoName)
+
+	//Indexes, Expired Sessions Are Removed by Mongo
+	_, err := sr.store.Indexes().CreateMany(sr.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "family", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return utils.GenerateError(ErrSessionRepoInit, err)
+	}
+
+	return nil
+}
+
+func (sr SessionRepoImpl) CreateSession(session *models.Session) (string, error) {
+	insertResult, err := sr.store.InsertOne(sr.ctx, session)
+	if err != nil {
+		return "", utils.GenerateError(ErrSessionInsertion, err)
+	}
+
+	// Assert InsertedID to ObjectID
+	idObj, isObjID := insertResult.InsertedID.(primitive.ObjectID)
+	if !isObjID {
+		return "", ErrSessionIDAssertion
+	}
+
+	return idObj.Hex(), nil
+}
+
+func (sr SessionRepoImpl) FindSessionsByUserID(userID string) ([]*models.Session, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	filter := bson.M{"user_id": objID}
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+	cursor, err := sr.store.Find(sr.ctx, filter, opts)
+	if err != nil {
+		return nil, utils.GenerateError(ErrSessionNotFound, err)
+	}
+
+	sessions := []*models.Session{}
+	if err := cursor.All(sr.ctx, &sessions); err != nil {
+		return nil, utils.GenerateError(ErrSessionNotFound, err)
+	}
+
+	return sessions, nil
+}
+
+func (sr SessionRepoImpl) TouchSession(family string, lastUsedAt time.Time, expiresAt time.Time) error {
+	query := bson.D{{Key: "family", Value: family}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "last_used_at", Value: lastUsedAt}, {Key: "expires_at", Value: expiresAt}}}}
+	res, err := sr.store.UpdateOne(sr.ctx, query, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrSessionUpdate, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrSessionNotFound, err)
+	}
+
+	return nil
+}
+
+func (sr SessionRepoImpl) DeleteSessionByID(id string, userID string) (*models.Session, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	session := &models.Session{}
+	filter := bson.M{"_id": objID, "user_id": userObjID}
+	err = sr.store.FindOneAndDelete(sr.ctx, filter).Decode(session)
+
+	if err != nil {
+		return nil, utils.GenerateError(ErrSessionNotFound, err)
+	}
+
+	return session, nil
+}
+
+func (sr SessionRepoImpl) DeleteSessionByFamily(family string) error {
+	_, err := sr.store.DeleteOne(sr.ctx, bson.M{"family": family})
+	if err != nil {
+		return utils.GenerateError(ErrSessionDelete, err)
+	}
+	return nil
+}
+
+func (sr SessionRepoImpl) DeleteSessionsByUserID(userID string) ([]*models.Session, error) {
+	sessions, err := sr.FindSessionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	_, err = sr.store.DeleteMany(sr.ctx, bson.M{"user_id": objID})
+	if err != nil {
+		return nil, utils.GenerateError(ErrSessionDelete, err)
+	}
+
+	return sessions, nil
+}
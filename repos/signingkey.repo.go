@@ -0,0 +1,19 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ISigningKeyRepo interface {
+	//Core
+	InitRepository(client *mongo.Client, dbName string, repoName string) error
+
+	//Public
+	CreateSigningKey(key *models.SigningKey) error
+	FindSigningKeys(ring string) ([]*models.SigningKey, error)
+	ExpireSigningKeys(ring string, exceptKeyID string, expiresAt time.Time) error
+	EncryptSigningKey(ring string, keyID string, privateKey string) error
+}
@@ -0,0 +1,94 @@
+package repos
+
+import (
+	"context"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SigningKeyRepoImpl struct {
+	ctx    context.Context
+	client *mongo.Client
+	store  *mongo.Collection
+}
+
+func NewSigningKeyRepo(ctx context.Context) *SigningKeyRepoImpl {
+	return &SigningKeyRepoImpl{ctx: ctx}
+}
+
+func (sr *SigningKeyRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
+	sr.client = client
+	sr.store = sr.client.Database(dbName).Collection(repoName)
+
+	_, err := sr.store.Indexes().CreateMany(sr.ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ring", Value: 1}, {Key: "kid", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return utils.GenerateError(ErrSigningKeyRepoInit, err)
+	}
+
+	return nil
+}
+
+func (sr SigningKeyRepoImpl) CreateSigningKey(key *models.SigningKey) error {
+	_, err := sr.store.InsertOne(sr.ctx, key)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return utils.GenerateError(ErrDuplicateSigningKey, err)
+		}
+		return utils.GenerateError(ErrSigningKeyInsertion, err)
+	}
+	return nil
+}
+
+// Returns the ring's keys that have not yet expired. The TTL monitor only
+// runs once a minute, so expired keys are filtered here as well.
+func (sr SigningKeyRepoImpl) FindSigningKeys(ring string) ([]*models.SigningKey, error) {
+	filter := bson.M{"ring": ring, "$or": bson.A{
+		bson.M{"expires_at": bson.M{"$exists": false}},
+		bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+	}}
+	cursor, err := sr.store.Find(sr.ctx, filter)
+	if err != nil {
+		return nil, utils.GenerateError(ErrSigningKeyNotFound, err)
+	}
+
+	keys := []*models.SigningKey{}
+	if err := cursor.All(sr.ctx, &keys); err != nil {
+		return nil, utils.GenerateError(ErrSigningKeyNotFound, err)
+	}
+
+	return keys, nil
+}
+
+// Schedules every other key in the ring without an expiry to expire at expiresAt.
+func (sr SigningKeyRepoImpl) ExpireSigningKeys(ring string, exceptKeyID string, expiresAt time.Time) error {
+	filter := bson.M{"ring": ring, "kid": bson.M{"$ne": exceptKeyID}, "expires_at": bson.M{"$exists": false}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "expires_at", Value: expiresAt}}}}
+	_, err := sr.store.UpdateMany(sr.ctx, filter, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrSigningKeyUpdate, err)
+	}
+
+	return nil
+}
+
+// Replaces a key stored before encryption with its encrypted form.
+func (sr SigningKeyRepoImpl) EncryptSigningKey(ring string, keyID string, privateKey string) error {
+	filter := bson.M{"ring": ring, "kid": keyID, "encrypted": bson.M{"$ne": true}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "private_key", Value: privateKey}, {Key: "encrypted", Value: true}}}}
+	_, err := sr.store.UpdateOne(sr.ctx, filter, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrSigningKeyUpdate, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package repos
+
+import (
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/redis/go-redis/v9"
+)
+
+type ITokenRepo interface {
+	//Core
+	InitRepository(client *redis.Client) error
+	DeinitRepository() error
+
+	//Public
+	CreateRefreshFamily(family string, jti string, ttl time.Duration) error
+	RotateRefreshToken(family string, jti string, newJti string, ttl time.Duration) error
+	RevokeRefreshFamily(family string) error
+	RefreshFamilyExists(family string) (bool, error)
+	DenylistToken(jti string, ttl time.Duration) error
+	IsTokenDenylisted(jti string) (bool, error)
+	GetTokenGeneration(userID string) (int64, error)
+	IncrementTokenGeneration(userID string) (int64, error)
+	CreateMFAChallenge(token string, userID string, ttl time.Duration) error
+	FindMFAChallenge(token string) (string, error)
+	FailMFAChallenge(token string, maxAttempts int) error
+	DeleteMFAChallenge(token string) error
+	StoreWebAuthnSession(key string, data []byte, ttl time.Duration) error
+	ConsumeWebAuthnSession(key string) ([]byte, error)
+	StoreOAuthState(state string, data []byte, ttl time.Duration) error
+	ConsumeOAuthState(state string) ([]byte, error)
+	FindUnknownSignIns(email string) (*models.UnknownSignIns, error)
+	RecordUnknownSignIn(email string, window time.Duration) (int, error)
+	LockUnknownSignIns(email string, lockedUntil time.Time) error
+}
@@ -0,0 +1,282 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	refreshFamilyPrefix   = "refresh_family:"
+	tokenDenylistPrefix   = "token_denylist:"
+	tokenGenerationPrefix = "token_generation:"
+	mfaChallengePrefix    = "mfa_challenge:"
+	webAuthnSessionPrefix = "webauthn_session:"
+	oauthStatePrefix      = "oauth_state:"
+	unknownSignInPrefix   = "unknown_signin:"
+)
+
+// Swaps the current jti of a family only if the presented jti is the current one.
+// Returns 1 when rotated, 0 when the family does not exist and -1 when an
+// already rotated jti was presented, in which case the family is revoked.
+var rotateRefreshScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return 0
+end
+if current ~= ARGV[1] then
+	redis.call("DEL", KEYS[1])
+	return -1
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+return 1
+`)
+
+type TokenRepoImpl struct {
+	ctx    context.Context
+	client *redis.Client
+}
+
+func NewTokenRepo(ctx context.Context) *TokenRepoImpl {
+	return &TokenRepoImpl{ctx: ctx}
+}
+
+func (tr *TokenRepoImpl) InitRepository(client *redis.Client) error {
+	tr.client = client
+	return nil
+}
+
+func (tr TokenRepoImpl) DeinitRepository() error {
+	err := tr.client.Close()
+	if err != nil {
+		return utils.GenerateError(ErrTokenRepoDeinit, err)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) CreateRefreshFamily(family string, jti string, ttl time.Duration) error {
+	err := tr.client.Set(tr.ctx, refreshFamilyPrefix+family, jti, ttl).Err()
+	if err != nil {
+		return utils.GenerateError(ErrStoreRefreshToken, err)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) RotateRefreshToken(family string, jti string, newJti string, ttl time.Duration) error {
+	result, err := rotateRefreshScript.Run(tr.ctx, tr.client, []string{refreshFamilyPrefix + family}, jti, newJti, ttl.Milliseconds()).Int()
+	if err != nil {
+		return utils.GenerateError(ErrStoreRefreshToken, err)
+	}
+
+	switch result {
+	case 0:
+		return ErrRefreshFamilyNotFound
+	case -1:
+		return ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+func (tr TokenRepoImpl) RevokeRefreshFamily(family string) error {
+	err := tr.client.Del(tr.ctx, refreshFamilyPrefix+family).Err()
+	if err != nil {
+		return utils.GenerateError(ErrRevokeRefreshToken, err)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) RefreshFamilyExists(family string) (bool, error) {
+	count, err := tr.client.Exists(tr.ctx, refreshFamilyPrefix+family).Result()
+	if err != nil {
+		return false, utils.GenerateError(ErrReadTokenState, err)
+	}
+	return count > 0, nil
+}
+
+func (tr TokenRepoImpl) DenylistToken(jti string, ttl time.Duration) error {
+	//Already Expired
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := tr.client.Set(tr.ctx, tokenDenylistPrefix+jti, 1, ttl).Err()
+	if err != nil {
+		return utils.GenerateError(ErrDenylistToken, err)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) IsTokenDenylisted(jti string) (bool, error) {
+	count, err := tr.client.Exists(tr.ctx, tokenDenylistPrefix+jti).Result()
+	if err != nil {
+		return false, utils.GenerateError(ErrReadTokenState, err)
+	}
+	return count > 0, nil
+}
+
+func (tr TokenRepoImpl) GetTokenGeneration(userID string) (int64, error) {
+	generation, err := tr.client.Get(tr.ctx, tokenGenerationPrefix+userID).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, utils.GenerateError(ErrReadTokenState, err)
+	}
+	return generation, nil
+}
+
+func (tr TokenRepoImpl) IncrementTokenGeneration(userID string) (int64, error) {
+	generation, err := tr.client.Incr(tr.ctx, tokenGenerationPrefix+userID).Result()
+	if err != nil {
+		return 0, utils.GenerateError(ErrIncrementTokenGeneration, err)
+	}
+	return generation, nil
+}
+
+func (tr TokenRepoImpl) CreateMFAChallenge(token string, userID string, ttl time.Duration) error {
+	key := mfaChallengePrefix + token
+	_, err := tr.client.TxPipelined(tr.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(tr.ctx, key, "user", userID, "attempts", 0)
+		pipe.Expire(tr.ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return utils.GenerateError(ErrStoreMFAChallenge, err)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) FindMFAChallenge(token string) (string, error) {
+	userID, err := tr.client.HGet(tr.ctx, mfaChallengePrefix+token, "user").Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrMFAChallengeNotFound
+	}
+	if err != nil {
+		return "", utils.GenerateError(ErrReadTokenState, err)
+	}
+	return userID, nil
+}
+
+// Counts a failed attempt, discarding the challenge once maxAttempts is reached.
+func (tr TokenRepoImpl) FailMFAChallenge(token string, maxAttempts int) error {
+	key := mfaChallengePrefix + token
+	attempts, err := tr.client.HIncrBy(tr.ctx, key, "attempts", 1).Result()
+	if err != nil {
+		return utils.GenerateError(ErrStoreMFAChallenge, err)
+	}
+
+	if attempts >= int64(maxAttempts) {
+		return tr.DeleteMFAChallenge(token)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) DeleteMFAChallenge(token string) error {
+	err := tr.client.Del(tr.ctx, mfaChallengePrefix+token).Err()
+	if err != nil {
+		return utils.GenerateError(ErrStoreMFAChallenge, err)
+	}
+	return nil
+}
+
+func (tr TokenRepoImpl) StoreWebAuthnSession(key string, data []byte, ttl time.Duration) error {
+	err := tr.client.Set(tr.ctx, webAuthnSessionPrefix+key, data, ttl).Err()
+	if err != nil {
+		return utils.GenerateError(ErrStoreWebAuthnSession, err)
+	}
+	return nil
+}
+
+// Ceremony sessions are single use, so reading one also deletes it.
+func (tr TokenRepoImpl) ConsumeWebAuthnSession(key string) ([]byte, error) {
+	data, err := tr.client.GetDel(tr.ctx, webAuthnSessionPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrWebAuthnSessionNotFound
+	}
+	if err != nil {
+		return nil, utils.GenerateError(ErrReadTokenState, err)
+	}
+	return data, nil
+}
+
+func (tr TokenRepoImpl) StoreOAuthState(state string, data []byte, ttl time.Duration) error {
+	err := tr.client.Set(tr.ctx, oauthStatePrefix+state, data, ttl).Err()
+	if err != nil {
+		return utils.GenerateError(ErrStoreOAuthState, err)
+	}
+	return nil
+}
+
+// A state is only honoured for a single callback.
+func (tr TokenRepoImpl) ConsumeOAuthState(state string) ([]byte, error) {
+	data, err := tr.client.GetDel(tr.ctx, oauthStatePrefix+state).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOAuthStateNotFound
+	}
+	if err != nil {
+		return nil, utils.GenerateError(ErrReadTokenState, err)
+	}
+	return data, nil
+}
+
+// Keyed by digest so emails typed at the sign-in form are not kept in Redis.
+func unknownSignInKey(email string) string {
+	return unknownSignInPrefix + utils.HashOneTimeToken(strings.ToLower(strings.TrimSpace(email)))
+}
+
+func (tr TokenRepoImpl) FindUnknownSignIns(email string) (*models.UnknownSignIns, error) {
+	fields, err := tr.client.HGetAll(tr.ctx, unknownSignInKey(email)).Result()
+	if err != nil {
+		return nil, utils.GenerateError(ErrReadTokenState, err)
+	}
+
+	attempts, _ := strconv.Atoi(fields["attempts"])
+	lastFailedAt, _ := strconv.ParseInt(fields["lastFailedAt"], 10, 64)
+	lockedUntil, _ := strconv.ParseInt(fields["lockedUntil"], 10, 64)
+
+	failures := &models.UnknownSignIns{Attempts: attempts}
+	if lastFailedAt > 0 {
+		failures.LastFailedAt = time.UnixMilli(lastFailedAt)
+	}
+	if lockedUntil > 0 {
+		failures.LockedUntil = time.UnixMilli(lockedUntil)
+	}
+	return failures, nil
+}
+
+// Counts a failed attempt, the count lapsing once window passes without another.
+func (tr TokenRepoImpl) RecordUnknownSignIn(email string, window time.Duration) (int, error) {
+	key := unknownSignInKey(email)
+
+	var attempts *redis.IntCmd
+	_, err := tr.client.TxPipelined(tr.ctx, func(pipe redis.Pipeliner) error {
+		attempts = pipe.HIncrBy(tr.ctx, key, "attempts", 1)
+		pipe.HSet(tr.ctx, key, "lastFailedAt", time.Now().UnixMilli())
+		pipe.PExpire(tr.ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, utils.GenerateError(ErrStoreUnknownSignIn, err)
+	}
+	return int(attempts.Val()), nil
+}
+
+func (tr TokenRepoImpl) LockUnknownSignIns(email string, lockedUntil time.Time) error {
+	key := unknownSignInKey(email)
+	_, err := tr.client.TxPipelined(tr.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(tr.ctx, key, "lockedUntil", lockedUntil.UnixMilli())
+		pipe.PExpireAt(tr.ctx, key, lockedUntil)
+		return nil
+	})
+	if err != nil {
+		return utils.GenerateError(ErrStoreUnknownSignIn, err)
+	}
+	return nil
+}
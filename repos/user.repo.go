@@ -1,6 +1,8 @@
 package repos
 
 import (
+	"time"
+
 	"github.com/AmadoJunior/Gipitty/models"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -20,9 +22,39 @@ type IUserRepo interface {
 	FindUserByID(id string) (*models.DBResponse, error)
 	FindUserByEmail(email string) (*models.DBResponse, error)
 	FindAndUpdateUserByID(id string, data *models.UpdateInput) (*models.DBResponse, error)
+	UpdateUserProfile(id string, profile *models.UpdateProfileInput) (*models.DBResponse, error)
 	UpdateUserById(id string, update *models.UpdateInput) error
 	UpdateUserByEmail(email string, update *models.UpdateInput) error
-	VerifyUserEmail(verificationCode string) error
-	StorePasswordResetToken(userEmail string, passwordResetToken string) error
+	StoreVerificationCode(id string, verificationCode string, expiresAt time.Time) error
+	VerifyUserEmail(verificationCode string) (*models.DBResponse, error)
+	StorePasswordResetToken(userEmail string, passwordResetToken string, expiresAt time.Time) error
+	FindUserByResetToken(passwordResetToken string) (*models.DBResponse, error)
 	ResetUserPassword(passwordResetToken string, newPassword string) error
+	UpdateUserPassword(id string, newPassword string) error
+	UpdatePasswordHash(id string, oldHash string, newHash string) error
+	RecordFailedLogin(id string, since time.Time) (*models.DBResponse, error)
+	LockUser(id string, lockedUntil time.Time, unlockToken string) error
+	ResetFailedLogins(id string) error
+	UnlockUser(unlockToken string) error
+	StorePendingTOTPSecret(id string, secret string) error
+	EnableTOTP(id string, secret string, recoveryCodes []string) error
+	DisableTOTP(id string) error
+	UseTOTPStep(id string, step int64) error
+	ConsumeRecoveryCode(id string, recoveryCode string) error
+	StoreEmailChange(id string, newEmail string, emailChangeToken string, expiresAt time.Time) error
+	ConfirmEmailChange(emailChangeToken string) (*models.DBResponse, error)
+	StoreMagicLinkToken(userEmail string, magicLinkToken string, expiresAt time.Time) error
+	ConsumeMagicLinkToken(magicLinkToken string) (*models.DBResponse, error)
+	FindUserByIdentity(provider string, subject string) (*models.DBResponse, error)
+	LinkIdentity(id string, identity *models.LinkedIdentity) error
+	UpdateUserRole(id string, role string) (*models.DBResponse, error)
+	FindUsers(query *models.UserQuery) ([]*models.DBResponse, int64, error)
+	MarkUserVerified(id string) (*models.DBResponse, error)
+	SetUserStatus(id string, status string, reason string) (*models.DBResponse, error)
+	AnonymizeUser(id string) error
+	ScheduleUserDeletion(id string, restoreToken string, purgeAt time.Time) error
+	RestoreUser(restoreToken string) (*models.DBResponse, error)
+	MigrateTokenDigests(convert func(string) (string, error), verificationCodeExpiresAt time.Time) (int64, error)
+	MigrateRecoveryCodeDigests(convert func(string) string) (int64, error)
+	FindUsersDueForPurge(now time.Time) ([]*models.DBResponse, error)
 }
@@ -2,6 +2,7 @@ package repos
 
 import (
 	"context"
+	"regexp"
 	"strings"
 	"time"
 
@@ -26,6 +27,16 @@ func NewUserRepo(ctx context.Context) *UserRepoImpl {
 func (ur *UserRepoImpl) InitRepository(client *mongo.Client, dbName string, repoName string) error {
 	ur.client = client
 	ur.store = ur.client.Database(dbName).Collection(repoName)
+
+	//Indexes, Email Changes Rely on Uniqueness
+	_, err := ur.store.Indexes().CreateOne(ur.ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return utils.GenerateError(ErrUserRepoInit, err)
+	}
+
 	return nil
 }
 
@@ -81,9 +92,12 @@ func (ur UserRepoImpl) FindUserByEmail(email string) (*models.DBResponse, error)
 	filter := bson.M{"email": strings.ToLower(email)}
 	err := ur.store.FindOne(ur.ctx, filter).Decode(user)
 
-	if err != nil {
+	if err == mongo.ErrNoDocuments {
 		return nil, utils.GenerateError(ErrUserNotFound, err)
 	}
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserQuery, err)
+	}
 
 	return user, nil
 }
@@ -112,6 +126,16 @@ func (ur UserRepoImpl) FindAndUpdateUserByID(id string, data *models.UpdateInput
 	return updatedUser, nil
 }
 
+func (ur UserRepoImpl) UpdateUserProfile(id string, profile *models.UpdateProfileInput) (*models.DBResponse, error) {
+	doc, err := utils.ToDoc(profile)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidUpdateInput, err)
+	}
+
+	set := append(*doc, bson.E{Key: "updated_at", Value: time.Now()})
+	return ur.findAndUpdateUserByObjectID(id, bson.D{{Key: "$set", Value: set}})
+}
+
 func (ur UserRepoImpl) UpdateUserById(id string, update *models.UpdateInput) error {
 	// Convert String to ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
@@ -161,14 +185,39 @@ func (ur UserRepoImpl) UpdateUserByEmail(email string, update *models.UpdateInpu
 	return nil
 }
 
-func (ur UserRepoImpl) VerifyUserEmail(verificationCode string) error {
-	query := bson.D{{Key: "verificationCode", Value: verificationCode}}
-	update := bson.D{{Key: "$set", Value: bson.D{{Key: "verified", Value: true}}}, {Key: "$unset", Value: bson.D{{Key: "verificationCode", Value: ""}}}}
+func (ur UserRepoImpl) StoreVerificationCode(id string, verificationCode string, expiresAt time.Time) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "verificationCode", Value: verificationCode}, {Key: "verificationCodeExpiresAt", Value: expiresAt}}}}
+	return ur.updateUserByObjectID(id, update)
+}
+
+func (ur UserRepoImpl) VerifyUserEmail(verificationCode string) (*models.DBResponse, error) {
+	//Codes Issued Before Expiry Existed Have None
+	query := bson.M{"verificationCode": verificationCode, "$or": bson.A{
+		bson.M{"verificationCodeExpiresAt": bson.M{"$gt": time.Now()}},
+		bson.M{"verificationCodeExpiresAt": bson.M{"$exists": false}},
+	}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "verified", Value: true}}}, {Key: "$unset", Value: bson.D{{Key: "verificationCode", Value: ""}, {Key: "verificationCodeExpiresAt", Value: ""}}}}
 
+	var user *models.DBResponse
+	err := ur.store.FindOneAndUpdate(ur.ctx, query, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&user)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserVerification, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) StorePasswordResetToken(userEmail string, passwordResetToken string, expiresAt time.Time) error {
+	query := bson.D{{Key: "email", Value: strings.ToLower(userEmail)}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "passwordResetToken", Value: passwordResetToken}, {Key: "passwordResetAt", Value: expiresAt}}}}
 	res, err := ur.store.UpdateOne(ur.ctx, query, update)
 
 	if err != nil {
-		return utils.GenerateError(ErrUserVerification, err)
+		return utils.GenerateError(ErrStorePasswordResetToken, err)
 	}
 
 	if res.MatchedCount < 1 {
@@ -178,13 +227,104 @@ func (ur UserRepoImpl) VerifyUserEmail(verificationCode string) error {
 	return nil
 }
 
-func (ur UserRepoImpl) StorePasswordResetToken(userEmail string, passwordResetToken string) error {
+// Finds the user owning an unexpired reset token. passwordResetAt holds the
+// token's expiry.
+func (ur UserRepoImpl) FindUserByResetToken(passwordResetToken string) (*models.DBResponse, error) {
+	query := bson.M{"passwordResetToken": passwordResetToken, "passwordResetAt": bson.M{"$gt": time.Now()}, "status": bson.M{"$nin": inactiveStatuses}}
+
+	user := &models.DBResponse{}
+	if err := ur.store.FindOne(ur.ctx, query).Decode(user); err != nil {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) ResetUserPassword(passwordResetToken string, newPassword string) error {
+	query := bson.M{"passwordResetToken": passwordResetToken, "passwordResetAt": bson.M{"$gt": time.Now()}, "status": bson.M{"$nin": inactiveStatuses}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: newPassword}}}, {Key: "$unset", Value: bson.D{{Key: "passwordResetToken", Value: ""}, {Key: "passwordResetAt", Value: ""}}}}
+	res, err := ur.store.UpdateOne(ur.ctx, query, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrResetPassword, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return nil
+}
+
+// Also drops any outstanding reset token, it was issued for the old password.
+func (ur UserRepoImpl) UpdateUserPassword(id string, newPassword string) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "password", Value: newPassword}, {Key: "updated_at", Value: time.Now()}}},
+		{Key: "$unset", Value: bson.D{{Key: "passwordResetToken", Value: ""}, {Key: "passwordResetAt", Value: ""}}},
+	}
+	return ur.updateUserByObjectID(id, update)
+}
+
+// Swaps the stored hash for an equivalent one, unless the password changed
+// in the meantime.
+func (ur UserRepoImpl) UpdatePasswordHash(id string, oldHash string, newHash string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: newHash}}}}
+	_, err = ur.store.UpdateOne(ur.ctx, bson.M{"_id": objID, "password": oldHash}, update)
+	if err != nil {
+		return utils.GenerateError(ErrUserUpdate, err)
+	}
+
+	return nil
+}
+
+func (ur UserRepoImpl) StoreEmailChange(id string, newEmail string, emailChangeToken string, expiresAt time.Time) error {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "pendingEmail", Value: strings.ToLower(newEmail)},
+		{Key: "emailChangeToken", Value: emailChangeToken},
+		{Key: "emailChangeExpiresAt", Value: expiresAt},
+	}}}
+	return ur.updateUserByObjectID(id, update)
+}
+
+// Swaps in the pending email of an unexpired change request, clearing the
+// request in the same operation so each link works at most once.
+func (ur UserRepoImpl) ConfirmEmailChange(emailChangeToken string) (*models.DBResponse, error) {
+	query := bson.M{"emailChangeToken": emailChangeToken, "emailChangeExpiresAt": bson.M{"$gt": time.Now()}}
+
+	pending := &models.DBResponse{}
+	if err := ur.store.FindOne(ur.ctx, query).Decode(pending); err != nil {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "email", Value: pending.PendingEmail}, {Key: "updated_at", Value: time.Now()}}},
+		{Key: "$unset", Value: bson.D{{Key: "pendingEmail", Value: ""}, {Key: "emailChangeToken", Value: ""}, {Key: "emailChangeExpiresAt", Value: ""}}},
+	}
+	result := ur.store.FindOneAndUpdate(ur.ctx, query, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	user := &models.DBResponse{}
+	if err := result.Decode(user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, utils.GenerateError(ErrDuplicateEmail, err)
+		}
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) StoreMagicLinkToken(userEmail string, magicLinkToken string, expiresAt time.Time) error {
 	query := bson.D{{Key: "email", Value: strings.ToLower(userEmail)}}
-	update := bson.D{{Key: "$set", Value: bson.D{{Key: "passwordResetToken", Value: passwordResetToken}, {Key: "passwordResetAt", Value: time.Now().Add(time.Minute * 15)}}}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "magicLinkToken", Value: magicLinkToken}, {Key: "magicLinkExpiresAt", Value: expiresAt}}}}
 	res, err := ur.store.UpdateOne(ur.ctx, query, update)
 
 	if err != nil {
-		return utils.GenerateError(ErrStorePasswordResetToken, err)
+		return utils.GenerateError(ErrStoreMagicLinkToken, err)
 	}
 
 	if res.MatchedCount < 1 {
@@ -194,13 +334,129 @@ func (ur UserRepoImpl) StorePasswordResetToken(userEmail string, passwordResetTo
 	return nil
 }
 
-func (ur UserRepoImpl) ResetUserPassword(passwordResetToken string, newPassword string) error {
-	query := bson.D{{Key: "passwordResetToken", Value: passwordResetToken}}
-	update := bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: newPassword}}}, {Key: "$unset", Value: bson.D{{Key: "passwordResetToken", Value: ""}, {Key: "passwordResetAt", Value: ""}}}}
+// Finds the user owning an unexpired magic link and clears it in the same
+// operation, so each link signs in at most once.
+func (ur UserRepoImpl) ConsumeMagicLinkToken(magicLinkToken string) (*models.DBResponse, error) {
+	query := bson.M{"magicLinkToken": magicLinkToken, "magicLinkExpiresAt": bson.M{"$gt": time.Now()}}
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: "magicLinkToken", Value: ""}, {Key: "magicLinkExpiresAt", Value: ""}}}}
+	result := ur.store.FindOneAndUpdate(ur.ctx, query, update)
+
+	user := &models.DBResponse{}
+	if err := result.Decode(user); err != nil {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) RecordFailedLogin(id string, since time.Time) (*models.DBResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	//Count Attempts Within the Window
+	query := bson.M{"_id": objID, "lastFailedLoginAt": bson.M{"$gte": since}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "failedLoginAttempts", Value: 1}}}, {Key: "$set", Value: bson.D{{Key: "lastFailedLoginAt", Value: now}}}}
+
+	var user *models.DBResponse
+	err = ur.store.FindOneAndUpdate(ur.ctx, query, update, opts).Decode(&user)
+	if err == nil {
+		return user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, utils.GenerateError(ErrUserUpdate, err)
+	}
+
+	//Start a New Window
+	query = bson.M{"_id": objID}
+	update = bson.D{{Key: "$set", Value: bson.D{{Key: "failedLoginAttempts", Value: 1}, {Key: "lastFailedLoginAt", Value: now}}}}
+	if err := ur.store.FindOneAndUpdate(ur.ctx, query, update, opts).Decode(&user); err != nil {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) LockUser(id string, lockedUntil time.Time, unlockToken string) error {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "lockedUntil", Value: lockedUntil}, {Key: "unlockToken", Value: unlockToken}}}}
+	return ur.updateUserByObjectID(id, update)
+}
+
+func (ur UserRepoImpl) ResetFailedLogins(id string) error {
+	return ur.updateUserByObjectID(id, unsetLockout)
+}
+
+func (ur UserRepoImpl) UnlockUser(unlockToken string) error {
+	query := bson.D{{Key: "unlockToken", Value: unlockToken}}
+	res, err := ur.store.UpdateOne(ur.ctx, query, unsetLockout)
+
+	if err != nil {
+		return utils.GenerateError(ErrUserUpdate, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return nil
+}
+
+// Statuses that block signing in. Missing statuses count as active.
+var inactiveStatuses = bson.A{models.StatusSuspended, models.StatusDeleted}
+
+// Fields holding digests of one-time tokens mailed to the user.
+var oneTimeTokenFields = []string{"verificationCode", "passwordResetToken", "unlockToken", "magicLinkToken", "restoreToken", "emailChangeToken"}
+
+var unsetLockout = bson.D{{Key: "$unset", Value: bson.D{
+	{Key: "failedLoginAttempts", Value: ""},
+	{Key: "lastFailedLoginAt", Value: ""},
+	{Key: "lockedUntil", Value: ""},
+	{Key: "unlockToken", Value: ""},
+}}}
+
+func (ur UserRepoImpl) StorePendingTOTPSecret(id string, secret string) error {
+	return ur.updateUserByObjectID(id, bson.D{{Key: "$set", Value: bson.D{{Key: "totpPendingSecret", Value: secret}}}})
+}
+
+func (ur UserRepoImpl) EnableTOTP(id string, secret string, recoveryCodes []string) error {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "totpEnabled", Value: true}, {Key: "totpSecret", Value: secret}, {Key: "recoveryCodes", Value: recoveryCodes}}},
+		{Key: "$unset", Value: bson.D{{Key: "totpPendingSecret", Value: ""}, {Key: "totpLastUsedStep", Value: ""}}},
+	}
+	return ur.updateUserByObjectID(id, update)
+}
+
+func (ur UserRepoImpl) DisableTOTP(id string) error {
+	update := bson.D{{Key: "$unset", Value: bson.D{
+		{Key: "totpEnabled", Value: ""},
+		{Key: "totpSecret", Value: ""},
+		{Key: "totpPendingSecret", Value: ""},
+		{Key: "totpLastUsedStep", Value: ""},
+		{Key: "recoveryCodes", Value: ""},
+	}}}
+	return ur.updateUserByObjectID(id, update)
+}
+
+// Records the time step of an accepted code, failing if it was already used.
+func (ur UserRepoImpl) UseTOTPStep(id string, step int64) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	query := bson.M{"_id": objID, "$or": bson.A{
+		bson.M{"totpLastUsedStep": bson.M{"$exists": false}},
+		bson.M{"totpLastUsedStep": bson.M{"$lt": step}},
+	}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "totpLastUsedStep", Value: step}}}}
 	res, err := ur.store.UpdateOne(ur.ctx, query, update)
 
 	if err != nil {
-		return utils.GenerateError(ErrResetPassword, err)
+		return utils.GenerateError(ErrUserUpdate, err)
 	}
 
 	if res.MatchedCount < 1 {
@@ -209,3 +465,335 @@ func (ur UserRepoImpl) ResetUserPassword(passwordResetToken string, newPassword
 
 	return nil
 }
+
+func (ur UserRepoImpl) ConsumeRecoveryCode(id string, recoveryCode string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	query := bson.M{"_id": objID, "recoveryCodes": recoveryCode}
+	update := bson.D{{Key: "$pull", Value: bson.D{{Key: "recoveryCodes", Value: recoveryCode}}}}
+	res, err := ur.store.UpdateOne(ur.ctx, query, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrUserUpdate, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return nil
+}
+
+func (ur UserRepoImpl) FindUserByIdentity(provider string, subject string) (*models.DBResponse, error) {
+	user := &models.DBResponse{}
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+	err := ur.store.FindOne(ur.ctx, filter).Decode(user)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserQuery, err)
+	}
+
+	return user, nil
+}
+
+// Links an external identity unless the user already has one from the same provider.
+func (ur UserRepoImpl) LinkIdentity(id string, identity *models.LinkedIdentity) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	query := bson.M{"_id": objID, "identities.provider": bson.M{"$ne": identity.Provider}}
+	update := bson.D{{Key: "$push", Value: bson.D{{Key: "identities", Value: identity}}}}
+	res, err := ur.store.UpdateOne(ur.ctx, query, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrLinkIdentity, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrDuplicateIdentity, err)
+	}
+
+	return nil
+}
+
+func (ur UserRepoImpl) UpdateUserRole(id string, role string) (*models.DBResponse, error) {
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "role", Value: role}, {Key: "updated_at", Value: time.Now()}}}}
+	return ur.findAndUpdateUserByObjectID(id, update)
+}
+
+func (ur UserRepoImpl) FindUsers(query *models.UserQuery) ([]*models.DBResponse, int64, error) {
+	filter := bson.M{}
+	if query.Verified != nil {
+		filter["verified"] = *query.Verified
+	}
+	if query.Role != "" {
+		filter["role"] = query.Role
+	}
+	if query.Status == models.StatusActive {
+		filter["status"] = bson.M{"$nin": inactiveStatuses}
+	} else if query.Status != "" {
+		filter["status"] = query.Status
+	}
+
+	createdAt := bson.M{}
+	if !query.CreatedAfter.IsZero() {
+		createdAt["$gte"] = query.CreatedAfter
+	}
+	if !query.CreatedBefore.IsZero() {
+		createdAt["$lt"] = query.CreatedBefore
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	if query.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query.Search), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"name": pattern}, bson.M{"email": pattern}}
+	}
+
+	total, err := ur.store.CountDocuments(ur.ctx, filter)
+	if err != nil {
+		return nil, 0, utils.GenerateError(ErrUserQuery, err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((query.Page - 1) * query.Limit)).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := ur.store.Find(ur.ctx, filter, opts)
+	if err != nil {
+		return nil, 0, utils.GenerateError(ErrUserQuery, err)
+	}
+
+	users := []*models.DBResponse{}
+	if err := cursor.All(ur.ctx, &users); err != nil {
+		return nil, 0, utils.GenerateError(ErrUserQuery, err)
+	}
+
+	return users, total, nil
+}
+
+func (ur UserRepoImpl) MarkUserVerified(id string) (*models.DBResponse, error) {
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "verified", Value: true}, {Key: "updated_at", Value: time.Now()}}},
+		{Key: "$unset", Value: bson.D{{Key: "verificationCode", Value: ""}, {Key: "verificationCodeExpiresAt", Value: ""}}},
+	}
+	return ur.findAndUpdateUserByObjectID(id, update)
+}
+
+func (ur UserRepoImpl) SetUserStatus(id string, status string, reason string) (*models.DBResponse, error) {
+	now := time.Now()
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: status}, {Key: "statusReason", Value: reason}, {Key: "statusChangedAt", Value: now}, {Key: "updated_at", Value: now}}},
+		{Key: "$unset", Value: bson.D{{Key: "purgeAt", Value: ""}, {Key: "restoreToken", Value: ""}}},
+	}
+	return ur.findAndUpdateUserByObjectID(id, update)
+}
+
+func (ur UserRepoImpl) ScheduleUserDeletion(id string, restoreToken string, purgeAt time.Time) error {
+	now := time.Now()
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "status", Value: models.StatusDeleted},
+		{Key: "statusReason", Value: "deleted by owner"},
+		{Key: "statusChangedAt", Value: now},
+		{Key: "purgeAt", Value: purgeAt},
+		{Key: "restoreToken", Value: restoreToken},
+		{Key: "updated_at", Value: now},
+	}}}
+	return ur.updateUserByObjectID(id, update)
+}
+
+// Reactivates an account deleted by its owner, as long as its grace period
+// has not run out.
+func (ur UserRepoImpl) RestoreUser(restoreToken string) (*models.DBResponse, error) {
+	query := bson.M{"restoreToken": restoreToken, "status": models.StatusDeleted, "purgeAt": bson.M{"$gt": time.Now()}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: models.StatusActive}, {Key: "statusChangedAt", Value: time.Now()}}},
+		{Key: "$unset", Value: bson.D{{Key: "statusReason", Value: ""}, {Key: "purgeAt", Value: ""}, {Key: "restoreToken", Value: ""}}},
+	}
+	result := ur.store.FindOneAndUpdate(ur.ctx, query, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	user := &models.DBResponse{}
+	if err := result.Decode(user); err != nil {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) FindUsersDueForPurge(now time.Time) ([]*models.DBResponse, error) {
+	filter := bson.M{"status": models.StatusDeleted, "purgeAt": bson.M{"$lte": now}}
+	cursor, err := ur.store.Find(ur.ctx, filter)
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserQuery, err)
+	}
+
+	users := []*models.DBResponse{}
+	if err := cursor.All(ur.ctx, &users); err != nil {
+		return nil, utils.GenerateError(ErrUserQuery, err)
+	}
+
+	return users, nil
+}
+
+// Replaces the user with a tombstone holding no personal data. Only the id,
+// role and dates remain so anything referencing the account still resolves.
+func (ur UserRepoImpl) AnonymizeUser(id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	user := &models.DBResponse{}
+	if err := ur.store.FindOne(ur.ctx, bson.M{"_id": objID}).Decode(user); err != nil {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	//No purgeAt, so Purge Runs Skip It
+	now := time.Now()
+	tombstone := bson.M{
+		"_id":             objID,
+		"name":            "Deleted user",
+		"email":           "deleted-" + id + "@invalid",
+		"password":        "",
+		"role":            user.Role,
+		"verified":        false,
+		"status":          models.StatusDeleted,
+		"statusChangedAt": now,
+		"created_at":      user.CreatedAt,
+		"updated_at":      now,
+	}
+	_, err = ur.store.ReplaceOne(ur.ctx, bson.M{"_id": objID}, tombstone)
+
+	if err != nil {
+		return utils.GenerateError(ErrUserDelete, err)
+	}
+
+	return nil
+}
+
+func (ur UserRepoImpl) findAndUpdateUserByObjectID(id string, update bson.D) (*models.DBResponse, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	result := ur.store.FindOneAndUpdate(ur.ctx, bson.M{"_id": objID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+
+	var user *models.DBResponse
+	if err := result.Decode(&user); err != nil {
+		return nil, utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return user, nil
+}
+
+func (ur UserRepoImpl) updateUserByObjectID(id string, update bson.D) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.GenerateError(ErrInvalidIDHex, err)
+	}
+
+	res, err := ur.store.UpdateOne(ur.ctx, bson.M{"_id": objID}, update)
+
+	if err != nil {
+		return utils.GenerateError(ErrUserUpdate, err)
+	}
+
+	if res.MatchedCount < 1 {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	return nil
+}
+
+// Also gives verification codes issued before codes expired an expiry.
+func (ur UserRepoImpl) MigrateTokenDigests(convert func(string) (string, error), verificationCodeExpiresAt time.Time) (int64, error) {
+	migrated, err := migrateTokenDigests(ur.ctx, ur.store, oneTimeTokenFields, convert)
+	if err != nil {
+		return migrated, err
+	}
+
+	query := bson.M{"verificationCode": bson.M{"$exists": true}, "verificationCodeExpiresAt": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"verificationCodeExpiresAt": verificationCodeExpiresAt}}
+	if _, err := ur.store.UpdateMany(ur.ctx, query, update); err != nil {
+		return migrated, utils.GenerateError(ErrTokenMigration, err)
+	}
+
+	return migrated, nil
+}
+
+// Rewrites every stored recovery code digest with convert. Stored digests
+// cannot tell which form they are in, so callers must only run this once.
+func (ur UserRepoImpl) MigrateRecoveryCodeDigests(convert func(string) string) (int64, error) {
+	filter := bson.M{"recoveryCodes.0": bson.M{"$exists": true}}
+	cursor, err := ur.store.Find(ur.ctx, filter, options.Find().SetProjection(bson.M{"recoveryCodes": 1}))
+	if err != nil {
+		return 0, utils.GenerateError(ErrTokenMigration, err)
+	}
+
+	var users []*models.DBResponse
+	if err := cursor.All(ur.ctx, &users); err != nil {
+		return 0, utils.GenerateError(ErrTokenMigration, err)
+	}
+
+	var migrated int64
+	for _, user := range users {
+		codes := make([]string, len(user.RecoveryCodes))
+		for i, code := range user.RecoveryCodes {
+			codes[i] = convert(code)
+		}
+
+		//Only if Unchanged Since Read
+		query := bson.M{"_id": user.ID, "recoveryCodes": user.RecoveryCodes}
+		res, err := ur.store.UpdateOne(ur.ctx, query, bson.M{"$set": bson.M{"recoveryCodes": codes}})
+		if err != nil {
+			return migrated, utils.GenerateError(ErrTokenMigration, err)
+		}
+		migrated += res.ModifiedCount
+	}
+	return migrated, nil
+}
+
+// Rewrites token fields not yet holding a SHA-256 digest with convert.
+// Values convert rejects are left alone, lookups by digest never match them.
+func migrateTokenDigests(ctx context.Context, store *mongo.Collection, fields []string, convert func(string) (string, error)) (int64, error) {
+	var migrated int64
+	for _, field := range fields {
+		filter := bson.M{field: bson.M{"$type": "string", "$not": primitive.Regex{Pattern: "^[0-9a-f]{64}$"}}}
+		cursor, err := store.Find(ctx, filter, options.Find().SetProjection(bson.M{field: 1}))
+		if err != nil {
+			return migrated, utils.GenerateError(ErrTokenMigration, err)
+		}
+
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			return migrated, utils.GenerateError(ErrTokenMigration, err)
+		}
+
+		for _, doc := range docs {
+			stored, _ := doc[field].(string)
+			digest, err := convert(stored)
+			if err != nil {
+				continue
+			}
+
+			//Only if Unchanged Since Read
+			res, err := store.UpdateOne(ctx, bson.M{"_id": doc["_id"], field: stored}, bson.M{"$set": bson.M{field: digest}})
+			if err != nil {
+				return migrated, utils.GenerateError(ErrTokenMigration, err)
+			}
+			migrated += res.ModifiedCount
+		}
+	}
+	return migrated, nil
+}
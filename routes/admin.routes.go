@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminRouteController struct {
+	adminController controllers.AdminController
+	userService     services.IUserService
+	authService     services.IAuthService
+	apiKeyService   services.IAPIKeyService
+}
+
+func NewAdminRouteController(adminController controllers.AdminController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService) AdminRouteController {
+	return AdminRouteController{adminController, userService, authService, apiKeyService}
+}
+
+func (ac *AdminRouteController) AdminRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("/admin/users")
+	router.Use(middleware.DeserializeUser(ac.userService, ac.authService, ac.apiKeyService))
+	router.Use(middleware.RequireSession())
+
+	readUsers := middleware.RequirePermission(models.PermissionReadUsers)
+	manageUsers := middleware.RequirePermission(models.PermissionManageUsers)
+	manageRoles := middleware.RequirePermission(models.PermissionManageRoles)
+
+	router.GET("", readUsers, ac.adminController.GetUsers)
+	router.GET("/:id", readUsers, ac.adminController.GetUser)
+	router.PATCH("/:id/role", manageRoles, ac.adminController.UpdateUserRole)
+	router.POST("/:id/verify", manageUsers, ac.adminController.VerifyUser)
+	router.POST("/:id/suspend", manageUsers, ac.adminController.SuspendUser)
+	router.POST("/:id/reactivate", manageUsers, ac.adminController.ReactivateUser)
+	router.POST("/:id/resetpassword", manageUsers, ac.adminController.ResetUserPassword)
+	router.DELETE("/:id", manageUsers, ac.adminController.DeleteUser)
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyRouteController struct {
+	apiKeyController controllers.APIKeyController
+	userService      services.IUserService
+	authService      services.IAuthService
+	apiKeyService    services.IAPIKeyService
+}
+
+func NewAPIKeyRouteController(apiKeyController controllers.APIKeyController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService) APIKeyRouteController {
+	return APIKeyRouteController{apiKeyController, userService, authService, apiKeyService}
+}
+
+func (ac *APIKeyRouteController) APIKeyRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("/users/me/apikeys")
+	router.Use(middleware.DeserializeUser(ac.userService, ac.authService, ac.apiKeyService))
+	router.Use(middleware.RequireSession())
+	router.POST("", ac.apiKeyController.CreateAPIKey)
+	router.GET("", ac.apiKeyController.GetAPIKeys)
+	router.DELETE("/:id", ac.apiKeyController.DeleteAPIKey)
+}
@@ -1,26 +1,54 @@
 package routes
 
 import (
+	"github.com/AmadoJunior/Gipitty/config"
 	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/AmadoJunior/Gipitty/services"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthRouteController struct {
 	authController controllers.AuthController
+	userService    services.IUserService
+	authService    services.IAuthService
+	apiKeyService  services.IAPIKeyService
+	rateLimiter    middleware.RateLimiter
 }
 
-func NewAuthRouteController(authController controllers.AuthController) AuthRouteController {
-	return AuthRouteController{authController}
+func NewAuthRouteController(authController controllers.AuthController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService, rateLimiter middleware.RateLimiter) AuthRouteController {
+	return AuthRouteController{authController, userService, authService, apiKeyService, rateLimiter}
 }
 
 func (rc *AuthRouteController) AuthRoute(rg *gin.RouterGroup) {
 	router := rg.Group("auth")
+	appConfig, _ := config.LoadConfig(".")
 
-	router.POST("/register", rc.authController.SignUpUser)
-	router.POST("/login", rc.authController.SignInUser)
+	//Rate Limits
+	loginLimit := middleware.RateLimit(rc.rateLimiter, "login", config.MustParseRateLimit(appConfig.RateLimitLogin), middleware.ByIP, middleware.ByEmail)
+	registerLimit := middleware.RateLimit(rc.rateLimiter, "register", config.MustParseRateLimit(appConfig.RateLimitRegister), middleware.ByIP, middleware.ByEmail)
+	forgotPasswordLimit := middleware.RateLimit(rc.rateLimiter, "forgotpassword", config.MustParseRateLimit(appConfig.RateLimitForgotPassword), middleware.ByIP, middleware.ByEmail)
+	verifyEmailLimit := middleware.RateLimit(rc.rateLimiter, "verifyemail", config.MustParseRateLimit(appConfig.RateLimitVerifyEmail), middleware.ByIP)
+	resendVerificationLimit := middleware.RateLimit(rc.rateLimiter, "resendverification", config.MustParseRateLimit(appConfig.RateLimitResendVerification), middleware.ByIP, middleware.ByEmail)
+	mfaLimit := middleware.RateLimit(rc.rateLimiter, "mfa", config.MustParseRateLimit(appConfig.RateLimitMFA), middleware.ByIP)
+	magicLinkLimit := middleware.RateLimit(rc.rateLimiter, "magiclink", config.MustParseRateLimit(appConfig.RateLimitMagicLink), middleware.ByIP, middleware.ByEmail)
+	magicLinkSignInLimit := middleware.RateLimit(rc.rateLimiter, "magiclinksignin", config.MustParseRateLimit(appConfig.RateLimitLogin), middleware.ByIP)
+	unlockAccountLimit := middleware.RateLimit(rc.rateLimiter, "unlockaccount", config.MustParseRateLimit(appConfig.RateLimitUnlockAccount), middleware.ByIP)
+	restoreAccountLimit := middleware.RateLimit(rc.rateLimiter, "restoreaccount", config.MustParseRateLimit(appConfig.RateLimitRestoreAccount), middleware.ByIP)
+
+	router.POST("/register", registerLimit, rc.authController.SignUpUser)
+	router.POST("/login", loginLimit, rc.authController.SignInUser)
+	router.POST("/magiclink", magicLinkLimit, rc.authController.RequestMagicLink)
+	router.GET("/magiclink/:token", magicLinkSignInLimit, rc.authController.SignInWithMagicLink)
+	router.POST("/login/mfa", mfaLimit, rc.authController.VerifyMFA)
 	router.GET("/refresh", rc.authController.RefreshAccessToken)
 	router.GET("/logout", rc.authController.LogoutUser)
-	router.GET("/verifyemail/:verificationCode", rc.authController.VerifyEmail)
-	router.POST("/forgotpassword", rc.authController.ForgotPassword)
+	router.POST("/logoutall", middleware.DeserializeUser(rc.userService, rc.authService, rc.apiKeyService), middleware.RequireSession(), rc.authController.LogoutAllDevices)
+	router.POST("/verifyemail/resend", resendVerificationLimit, rc.authController.ResendVerificationEmail)
+	router.GET("/verifyemail/:verificationCode", verifyEmailLimit, rc.authController.VerifyEmail)
+	router.GET("/confirmemail/:emailChangeToken", verifyEmailLimit, rc.authController.ConfirmEmailChange)
+	router.GET("/unlockaccount/:unlockToken", unlockAccountLimit, rc.authController.UnlockAccount)
+	router.POST("/restoreaccount/:restoreToken", restoreAccountLimit, rc.authController.RestoreAccount)
+	router.POST("/forgotpassword", forgotPasswordLimit, rc.authController.ForgotPassword)
 	router.PATCH("/resetpassword/:resetToken", rc.authController.ResetPassword)
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSRouteController struct {
+	jwksController controllers.JWKSController
+}
+
+func NewJWKSRouteController(jwksController controllers.JWKSController) JWKSRouteController {
+	return JWKSRouteController{jwksController}
+}
+
+func (jc *JWKSRouteController) JWKSRoute(rg *gin.RouterGroup) {
+	router := rg.Group("/.well-known")
+	router.GET("/jwks.json", jc.jwksController.GetJWKS)
+}
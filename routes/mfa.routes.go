@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/gin-gonic/gin"
+)
+
+type MFARouteController struct {
+	mfaController controllers.MFAController
+	userService   services.IUserService
+	authService   services.IAuthService
+	apiKeyService services.IAPIKeyService
+	rateLimiter   middleware.RateLimiter
+}
+
+func NewMFARouteController(mfaController controllers.MFAController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService, rateLimiter middleware.RateLimiter) MFARouteController {
+	return MFARouteController{mfaController, userService, authService, apiKeyService, rateLimiter}
+}
+
+func (mc *MFARouteController) MFARoute(rg *gin.RouterGroup) {
+	appConfig, _ := config.LoadConfig(".")
+
+	//Rate Limits
+	disableLimit := middleware.RateLimit(mc.rateLimiter, "mfadisable", config.MustParseRateLimit(appConfig.RateLimitMFA), middleware.ByUser)
+
+	router := rg.Group("/users/me/2fa")
+	router.Use(middleware.DeserializeUser(mc.userService, mc.authService, mc.apiKeyService))
+	router.Use(middleware.RequireSession())
+	router.POST("/enroll", mc.mfaController.EnrollTOTP)
+	router.POST("/confirm", mc.mfaController.ConfirmTOTP)
+	router.POST("/disable", disableLimit, mc.mfaController.DisableTOTP)
+}
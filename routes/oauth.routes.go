@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthRouteController struct {
+	oauthController controllers.OAuthController
+	rateLimiter     middleware.RateLimiter
+}
+
+func NewOAuthRouteController(oauthController controllers.OAuthController, rateLimiter middleware.RateLimiter) OAuthRouteController {
+	return OAuthRouteController{oauthController, rateLimiter}
+}
+
+func (oc *OAuthRouteController) OAuthRoute(rg *gin.RouterGroup) {
+	router := rg.Group("/auth/oauth")
+	appConfig, _ := config.LoadConfig(".")
+
+	loginLimit := middleware.RateLimit(oc.rateLimiter, "oauth", config.MustParseRateLimit(appConfig.RateLimitLogin), middleware.ByIP)
+	router.GET("/:provider", loginLimit, oc.oauthController.BeginLogin)
+	router.GET("/:provider/callback", loginLimit, oc.oauthController.Callback)
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionRouteController struct {
+	sessionController controllers.SessionController
+	userService       services.IUserService
+	authService       services.IAuthService
+	apiKeyService     services.IAPIKeyService
+}
+
+func NewSessionRouteController(sessionController controllers.SessionController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService) SessionRouteController {
+	return SessionRouteController{sessionController, userService, authService, apiKeyService}
+}
+
+func (sc *SessionRouteController) SessionRoute(rg *gin.RouterGroup) {
+
+	router := rg.Group("/users/me/sessions")
+	router.Use(middleware.DeserializeUser(sc.userService, sc.authService, sc.apiKeyService))
+	router.Use(middleware.RequireSession())
+	router.GET("", sc.sessionController.GetSessions)
+	router.DELETE("/:id", sc.sessionController.DeleteSession)
+}
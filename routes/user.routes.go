@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"github.com/AmadoJunior/Gipitty/config"
 	"github.com/AmadoJunior/Gipitty/controllers"
 	"github.com/AmadoJunior/Gipitty/middleware"
 	"github.com/AmadoJunior/Gipitty/services"
@@ -10,15 +11,30 @@ import (
 type UserRouteController struct {
 	userController controllers.UserController
 	userService    services.IUserService
+	authService    services.IAuthService
+	apiKeyService  services.IAPIKeyService
+	rateLimiter    middleware.RateLimiter
 }
 
-func NewRouteUserController(userController controllers.UserController, userService services.IUserService) UserRouteController {
-	return UserRouteController{userController, userService}
+func NewRouteUserController(userController controllers.UserController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService, rateLimiter middleware.RateLimiter) UserRouteController {
+	return UserRouteController{userController, userService, authService, apiKeyService, rateLimiter}
 }
 
 func (uc *UserRouteController) UserRoute(rg *gin.RouterGroup) {
+	appConfig, _ := config.LoadConfig(".")
+
+	//Rate Limits
+	changePasswordLimit := middleware.RateLimit(uc.rateLimiter, "changepassword", config.MustParseRateLimit(appConfig.RateLimitChangePassword), middleware.ByUser)
+	emailChangeLimit := middleware.RateLimit(uc.rateLimiter, "emailchange", config.MustParseRateLimit(appConfig.RateLimitEmailChange), middleware.ByUser)
+	dataExportLimit := middleware.RateLimit(uc.rateLimiter, "dataexport", config.MustParseRateLimit(appConfig.RateLimitDataExport), middleware.ByUser)
 
 	router := rg.Group("/users")
-	router.Use(middleware.DeserializeUser(uc.userService))
+	router.Use(middleware.DeserializeUser(uc.userService, uc.authService, uc.apiKeyService))
 	router.GET("/me", uc.userController.GetMe)
+	router.PATCH("/me", uc.userController.UpdateMe)
+	router.DELETE("/me", middleware.RequireSession(), uc.userController.DeleteMe)
+	router.POST("/me/password", middleware.RequireSession(), changePasswordLimit, uc.userController.ChangePassword)
+	router.POST("/me/email", middleware.RequireSession(), emailChangeLimit, uc.userController.ChangeEmail)
+	router.POST("/me/export", middleware.RequireSession(), dataExportLimit, uc.userController.RequestExport)
+	router.GET("/me/export/:token", middleware.RequireSession(), uc.userController.DownloadExport)
 }
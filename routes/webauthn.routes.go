@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/controllers"
+	"github.com/AmadoJunior/Gipitty/middleware"
+	"github.com/AmadoJunior/Gipitty/services"
+	"github.com/gin-gonic/gin"
+)
+
+type WebAuthnRouteController struct {
+	webAuthnController controllers.WebAuthnController
+	userService        services.IUserService
+	authService        services.IAuthService
+	apiKeyService      services.IAPIKeyService
+	rateLimiter        middleware.RateLimiter
+}
+
+func NewWebAuthnRouteController(webAuthnController controllers.WebAuthnController, userService services.IUserService, authService services.IAuthService, apiKeyService services.IAPIKeyService, rateLimiter middleware.RateLimiter) WebAuthnRouteController {
+	return WebAuthnRouteController{webAuthnController, userService, authService, apiKeyService, rateLimiter}
+}
+
+func (wc *WebAuthnRouteController) WebAuthnRoute(rg *gin.RouterGroup) {
+	router := rg.Group("/auth/webauthn")
+	appConfig, _ := config.LoadConfig(".")
+
+	loginLimit := middleware.RateLimit(wc.rateLimiter, "webauthn", config.MustParseRateLimit(appConfig.RateLimitLogin), middleware.ByIP)
+	router.POST("/login/begin", loginLimit, wc.webAuthnController.BeginLogin)
+	router.POST("/login/finish", loginLimit, wc.webAuthnController.FinishLogin)
+
+	authenticated := router.Group("")
+	authenticated.Use(middleware.DeserializeUser(wc.userService, wc.authService, wc.apiKeyService))
+	authenticated.Use(middleware.RequireSession())
+	authenticated.POST("/register/begin", wc.webAuthnController.BeginRegistration)
+	authenticated.POST("/register/finish", wc.webAuthnController.FinishRegistration)
+	authenticated.GET("/credentials", wc.webAuthnController.GetCredentials)
+	authenticated.DELETE("/credentials/:id", wc.webAuthnController.DeleteCredential)
+}
@@ -0,0 +1,14 @@
+package services
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+type IAccountService interface {
+	DeleteAccount(user *models.DBResponse, password string, config *config.Config) error
+	RestoreAccount(restoreToken string) error
+	PurgeAccount(userID string) error
+	PurgeDeletedAccounts() error
+	Start()
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+// Handles the account lifecycle after sign-up. Owners delete their account
+// softly and can undo it until the grace period ends, after which their data
+// is removed for good and the account itself is anonymized.
+type AccountService struct {
+	userRepo       repos.IUserRepo
+	sessionRepo    repos.ISessionRepo
+	credentialRepo repos.ICredentialRepo
+	apiKeyRepo     repos.IAPIKeyRepo
+	exportRepo     repos.IExportRepo
+	tokenRepo      repos.ITokenRepo
+	hasher         IPasswordHasher
+	config         *config.Config
+	ctx            context.Context
+}
+
+func NewAccountService(userRepo repos.IUserRepo, sessionRepo repos.ISessionRepo, credentialRepo repos.ICredentialRepo, apiKeyRepo repos.IAPIKeyRepo, exportRepo repos.IExportRepo, tokenRepo repos.ITokenRepo, hasher IPasswordHasher, config *config.Config, ctx context.Context) IAccountService {
+	return &AccountService{userRepo, sessionRepo, credentialRepo, apiKeyRepo, exportRepo, tokenRepo, hasher, config, ctx}
+}
+
+func (as AccountService) DeleteAccount(user *models.DBResponse, password string, config *config.Config) error {
+	if err := as.hasher.Verify(user.Password, password); err != nil {
+		return utils.GenerateError(ErrIncorrectPassword, err)
+	}
+
+	restoreToken, restoreDigest := utils.NewOneTimeToken(32)
+	purgeAt := time.Now().Add(config.AccountDeletionGracePeriod)
+	err := as.userRepo.ScheduleUserDeletion(user.ID.Hex(), restoreDigest, purgeAt)
+	if err != nil {
+		return utils.GenerateError(ErrDeletingUser, err)
+	}
+
+	// Send Email
+	emailData := utils.EmailData{
+		URL:       config.Origin + "/restoreaccount/" + restoreToken,
+		FirstName: getFirstName(user.Name),
+		Subject:   "Your account will be deleted on " + purgeAt.Format("January 2, 2006"),
+	}
+
+	err = utils.SendEmail(user, &emailData, "accountDeleted.html")
+	if err != nil {
+		//Deleted, but Owner Not Notified
+		return utils.GenerateError(ErrSendingEmail, err)
+	}
+
+	return nil
+}
+
+func (as AccountService) RestoreAccount(restoreToken string) error {
+	_, err := as.userRepo.RestoreUser(utils.HashOneTimeToken(restoreToken))
+	if err != nil {
+		return utils.GenerateError(ErrRestoreTokenNotFound, err)
+	}
+	return nil
+}
+
+// Removes the data that only serves the owner and anonymizes the user. The
+// user document goes last so a failed purge is retried on the next run.
+func (as AccountService) PurgeAccount(userID string) error {
+	if _, err := as.userRepo.FindUserByID(userID); err != nil {
+		return utils.GenerateError(ErrUserIDNotFound, err)
+	}
+
+	sessions, err := as.sessionRepo.DeleteSessionsByUserID(userID)
+	if err != nil {
+		return utils.GenerateError(ErrDeletingUser, err)
+	}
+	for _, session := range sessions {
+		if err := as.tokenRepo.RevokeRefreshFamily(session.Family); err != nil {
+			return utils.GenerateError(ErrDeletingUser, err)
+		}
+	}
+
+	if err := as.credentialRepo.DeleteCredentialsByUserID(userID); err != nil {
+		return utils.GenerateError(ErrDeletingUser, err)
+	}
+
+	if err := as.apiKeyRepo.DeleteAPIKeysByUserID(userID); err != nil {
+		return utils.GenerateError(ErrDeletingUser, err)
+	}
+
+	if err := as.exportRepo.DeleteExportsByUserID(userID); err != nil {
+		return utils.GenerateError(ErrDeletingUser, err)
+	}
+
+	if err := as.userRepo.AnonymizeUser(userID); err != nil {
+		if errors.Is(err, repos.ErrUserNotFound) {
+			return nil
+		}
+		return utils.GenerateError(ErrDeletingUser, err)
+	}
+
+	return nil
+}
+
+func (as AccountService) PurgeDeletedAccounts() error {
+	users, err := as.userRepo.FindUsersDueForPurge(time.Now())
+	if err != nil {
+		return utils.GenerateError(ErrFindingUsers, err)
+	}
+
+	//One Failing Account Must Not Hold Back the Rest
+	failed := 0
+	for _, user := range users {
+		if err := as.PurgeAccount(user.ID.Hex()); err != nil {
+			log.Println("account purge:", user.ID.Hex(), err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return utils.GenerateError(ErrDeletingUser, fmt.Errorf("%d of %d accounts not purged", failed, len(users)))
+	}
+
+	return nil
+}
+
+func (as AccountService) Start() {
+	if as.config.AccountPurgeInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(as.config.AccountPurgeInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-as.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := as.PurgeDeletedAccounts(); err != nil {
+					log.Println("account purge:", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+type IAPIKeyService interface {
+	CreateAPIKey(user *models.DBResponse, input *models.CreateAPIKeyInput) (*models.CreatedAPIKey, error)
+	FindAPIKeysByUserId(userID string) ([]*models.APIKey, error)
+	RevokeAPIKey(id string, userID string) error
+	ValidateAPIKey(key string) (*models.APIKey, error)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Usage is recorded at most this often per key to avoid a write per request.
+const apiKeyTouchInterval = time.Minute
+
+var apiKeyScopes = map[string]bool{
+	models.ScopeRead:  true,
+	models.ScopeWrite: true,
+}
+
+type APIKeyService struct {
+	apiKeyRepo repos.IAPIKeyRepo
+	ctx        context.Context
+}
+
+func NewAPIKeyService(apiKeyRepo repos.IAPIKeyRepo, ctx context.Context) IAPIKeyService {
+	return &APIKeyService{apiKeyRepo, ctx}
+}
+
+func (as APIKeyService) CreateAPIKey(user *models.DBResponse, input *models.CreateAPIKeyInput) (*models.CreatedAPIKey, error) {
+	for _, scope := range input.Scopes {
+		if !apiKeyScopes[scope] {
+			return nil, utils.GenerateError(ErrInvalidAPIKeyInput, errors.New("unknown scope "+scope))
+		}
+	}
+
+	now := time.Now()
+	key, prefix := utils.GenerateAPIKey()
+	apiKey := &models.APIKey{
+		UserID:    user.ID,
+		Name:      input.Name,
+		Prefix:    prefix,
+		Hash:      utils.HashAPIKey(key),
+		Scopes:    input.Scopes,
+		CreatedAt: now,
+	}
+	if apiKey.Scopes == nil {
+		apiKey.Scopes = []string{}
+	}
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(now) {
+			return nil, utils.GenerateError(ErrInvalidAPIKeyInput, errors.New("expiry is in the past"))
+		}
+		apiKey.ExpiresAt = *input.ExpiresAt
+	}
+
+	id, err := as.apiKeyRepo.CreateAPIKey(apiKey)
+	if err != nil {
+		return nil, utils.GenerateError(ErrStoringAPIKey, err)
+	}
+	apiKey.ID, _ = primitive.ObjectIDFromHex(id)
+
+	return &models.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (as APIKeyService) FindAPIKeysByUserId(userID string) ([]*models.APIKey, error) {
+	apiKeys, err := as.apiKeyRepo.FindAPIKeysByUserID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrFindingAPIKeys, err)
+	}
+	return apiKeys, nil
+}
+
+func (as APIKeyService) RevokeAPIKey(id string, userID string) error {
+	err := as.apiKeyRepo.DeleteAPIKey(id, userID)
+	if err != nil {
+		//Not Found or Not Owned by User
+		if errors.Is(err, repos.ErrAPIKeyNotFound) || errors.Is(err, repos.ErrInvalidIDHex) {
+			return utils.GenerateError(ErrAPIKeyNotFound, err)
+		}
+		return utils.GenerateError(ErrStoringAPIKey, err)
+	}
+	return nil
+}
+
+func (as APIKeyService) ValidateAPIKey(key string) (*models.APIKey, error) {
+	apiKey, err := as.apiKeyRepo.FindAPIKeyByHash(utils.HashAPIKey(key))
+	if err != nil {
+		//Unknown or Revoked Key
+		return nil, utils.GenerateError(ErrInvalidAPIKey, err)
+	}
+
+	now := time.Now()
+	if !apiKey.ExpiresAt.IsZero() && !apiKey.ExpiresAt.After(now) {
+		return nil, utils.GenerateError(ErrInvalidAPIKey, errors.New("api key expired"))
+	}
+
+	if now.Sub(apiKey.LastUsedAt) > apiKeyTouchInterval {
+		if err := as.apiKeyRepo.TouchAPIKey(apiKey.ID, now); err != nil {
+			return nil, utils.GenerateError(ErrStoringAPIKey, err)
+		}
+	}
+
+	return apiKey, nil
+}
@@ -7,6 +7,15 @@ import (
 
 type IAuthService interface {
 	SignUpUser(*models.SignUpInput) (*models.DBResponse, error)
-	SignInUser(*models.SignInInput, *config.Config) (string, string, error)
-	RefreshAccessToken(string, *config.Config) (string, error)
+	SignInUser(*models.SignInInput, *models.ClientInfo, *config.Config) (*models.AuthTokens, error)
+	SendMagicLink(email string, config *config.Config) error
+	SignInWithMagicLink(token string, client *models.ClientInfo, config *config.Config) (*models.AuthTokens, error)
+	VerifyMFA(*models.MFALoginInput, *models.ClientInfo, *config.Config) (string, string, error)
+	CompleteSignIn(*models.DBResponse, *models.ClientInfo, *config.Config) (*models.AuthTokens, error)
+	IssueTokens(*models.DBResponse, *models.ClientInfo, *config.Config) (string, string, error)
+	RefreshAccessToken(string, *config.Config) (string, string, error)
+	ValidateAccessToken(string, *config.Config) (*models.TokenClaims, error)
+	LogoutUser(accessToken string, refreshToken string, config *config.Config) error
+	LogoutAllDevices(userID string) error
+	UnlockAccount(unlockToken string) error
 }
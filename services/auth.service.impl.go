@@ -2,7 +2,8 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log"
 	"strings"
 	"time"
 
@@ -10,25 +11,44 @@ import (
 	"github.com/AmadoJunior/Gipitty/models"
 	"github.com/AmadoJunior/Gipitty/repos"
 	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/thanhpk/randstr"
 )
 
+const mfaChallengeMaxAttempts = 5
+
 type AuthService struct {
-	UserRepo repos.IUserRepo
-	ctx      context.Context
+	UserRepo    repos.IUserRepo
+	TokenRepo   repos.ITokenRepo
+	SessionRepo repos.ISessionRepo
+	Policy      IPasswordPolicy
+	Hasher      IPasswordHasher
+	dummyHash   string
+	ctx         context.Context
 }
 
-func NewAuthService(userRepo repos.IUserRepo, ctx context.Context) IAuthService {
-	return &AuthService{userRepo, ctx}
+func NewAuthService(userRepo repos.IUserRepo, tokenRepo repos.ITokenRepo, sessionRepo repos.ISessionRepo, policy IPasswordPolicy, hasher IPasswordHasher, ctx context.Context) IAuthService {
+	//Verified Against for Unknown Emails, So They Cost the Same as Known Ones
+	dummyHash, err := hasher.Hash(randstr.Hex(32))
+	if err != nil {
+		log.Println("Failed to create dummy password hash:", err)
+	}
+
+	return &AuthService{userRepo, tokenRepo, sessionRepo, policy, hasher, dummyHash, ctx}
 }
 
 func (uc *AuthService) SignUpUser(user *models.SignUpInput) (*models.DBResponse, error) {
+	if err := checkPasswordPolicy(uc.Policy, user.Password, user.Email, user.Name); err != nil {
+		return nil, utils.GenerateError(ErrWeakPassword, err)
+	}
+
 	//Hash Password
 	hashedPassword := make(chan string)
 	errorChannel := make(chan error)
 	go func(password string) {
 		defer close(hashedPassword)
 		defer close(errorChannel)
-		result, err := utils.HashPassword(password)
+		result, err := uc.Hasher.Hash(password)
 		if err != nil {
 			errorChannel <- err
 		}
@@ -40,7 +60,8 @@ func (uc *AuthService) SignUpUser(user *models.SignUpInput) (*models.DBResponse,
 	user.UpdatedAt = user.CreatedAt
 	user.Email = strings.ToLower(user.Email)
 	user.Verified = false
-	user.Role = "user"
+	user.Role = models.RoleUser
+	user.Status = models.StatusActive
 	user.PasswordConfirm = ""
 
 	select {
@@ -66,57 +87,538 @@ func (uc *AuthService) SignUpUser(user *models.SignUpInput) (*models.DBResponse,
 	return newUser, nil
 }
 
-func (uc *AuthService) SignInUser(credentials *models.SignInInput, config *config.Config) (string, string, error) {
+func (uc *AuthService) SignInUser(credentials *models.SignInInput, client *models.ClientInfo, config *config.Config) (*models.AuthTokens, error) {
 	user, err := uc.UserRepo.FindUserByEmail(credentials.Email)
 	if err != nil {
-		//User Not Found
-		return "", "", utils.GenerateError(ErrUserNotFound, err)
+		//User Not Found, Still Costing a Hash and Counting Toward a Lockout
+		uc.Hasher.Verify(uc.dummyHash, credentials.Password)
+		if !errors.Is(err, repos.ErrUserNotFound) {
+			return nil, utils.GenerateError(ErrUserNotFound, err)
+		}
+		return nil, uc.recordUnknownSignIn(credentials.Email, config, err)
+	}
+
+	//Verified Before Any Other Check, So Every Outcome Costs a Hash
+	passwordErr := uc.Hasher.Verify(user.Password, credentials.Password)
+
+	if isLockedOut(user, time.Now()) {
+		//Locked or Within Progressive Delay
+		return nil, utils.GenerateError(ErrAccountLocked, errors.New("sign-in attempted while locked"))
+	}
+
+	if passwordErr != nil {
+		//Incorrect Password
+		return nil, recordFailedLogin(uc.UserRepo, user, config, ErrIncorrectPassword, passwordErr)
 	}
 
 	if !user.Verified {
 		//Not Verified
-		return "", "", utils.GenerateError(ErrUserNotVerified, err)
+		return nil, utils.GenerateError(ErrUserNotVerified, errors.New("sign-in attempted before verification"))
 	}
 
-	if err := utils.VerifyPassword(user.Password, credentials.Password); err != nil {
-		//Incorrect Password
-		return "", "", utils.GenerateError(ErrIncorrectPassword, err)
+	if err := checkAccountStatus(user); err != nil {
+		//Suspended or Deleted
+		return nil, err
+	}
+
+	//Upgrade Outdated Hash While the Plain Password Is at Hand
+	if uc.Hasher.NeedsRehash(user.Password) {
+		if err := uc.rehashPassword(user, credentials.Password); err != nil {
+			log.Println("Failed to rehash password:", err)
+		}
+	}
+
+	//With Two-Factor Enabled, Failures Are Only Cleared by VerifyMFA
+	if user.FailedLoginAttempts > 0 && !user.TOTPEnabled {
+		if err := uc.UserRepo.ResetFailedLogins(user.ID.Hex()); err != nil {
+			return nil, utils.GenerateError(ErrGeneratingToken, err)
+		}
+	}
+
+	return uc.CompleteSignIn(user, client, config)
+}
+
+func (uc AuthService) rehashPassword(user *models.DBResponse, password string) error {
+	hashedPassword, err := uc.Hasher.Hash(password)
+	if err != nil {
+		return utils.GenerateError(ErrHashingPassword, err)
+	}
+	if err := uc.UserRepo.UpdatePasswordHash(user.ID.Hex(), user.Password, hashedPassword); err != nil {
+		return utils.GenerateError(ErrUpdatingPassword, err)
+	}
+	user.Password = hashedPassword
+	return nil
+}
+
+// Finishes a first factor sign-in, either issuing tokens or, when two-factor
+// authentication is enabled, an MFA challenge to be redeemed with VerifyMFA.
+func (uc AuthService) CompleteSignIn(user *models.DBResponse, client *models.ClientInfo, config *config.Config) (*models.AuthTokens, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return nil, err
+	}
+
+	//Second Factor Required
+	if user.TOTPEnabled {
+		mfaToken, challenge := utils.NewOneTimeToken(32)
+		err := uc.TokenRepo.CreateMFAChallenge(challenge, user.ID.Hex(), config.MFAChallengeExpiresIn)
+		if err != nil {
+			return nil, utils.GenerateError(ErrStoringToken, err)
+		}
+		return &models.AuthTokens{MFAToken: mfaToken}, nil
+	}
+
+	access_token, refresh_token, err := uc.IssueTokens(user, client, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{AccessToken: access_token, RefreshToken: refresh_token}, nil
+}
+
+// Emails a single use sign-in link. Unknown and unverified addresses are
+// skipped silently so the endpoint does not reveal which accounts exist.
+func (uc AuthService) SendMagicLink(email string, config *config.Config) error {
+	user, err := uc.UserRepo.FindUserByEmail(email)
+	if err != nil || !user.Verified || !user.IsActive() {
+		return nil
+	}
+
+	token, digest := utils.NewOneTimeToken(32)
+	err = uc.UserRepo.StoreMagicLinkToken(user.Email, digest, time.Now().Add(config.MagicLinkExpiresIn))
+	if err != nil {
+		return utils.GenerateError(ErrStoringToken, err)
+	}
+
+	// Send Email
+	emailData := utils.EmailData{
+		URL:       config.Origin + "/magiclink/" + token,
+		FirstName: getFirstName(user.Name),
+		Subject:   "Your sign-in link (valid for " + utils.HumanizeDuration(config.MagicLinkExpiresIn) + ")",
+	}
+
+	err = utils.SendEmail(user, &emailData, "magicLink.html")
+	if err != nil {
+		return utils.GenerateError(ErrSendingEmail, err)
+	}
+
+	return nil
+}
+
+func (uc AuthService) SignInWithMagicLink(token string, client *models.ClientInfo, config *config.Config) (*models.AuthTokens, error) {
+	user, err := uc.UserRepo.ConsumeMagicLinkToken(utils.HashOneTimeToken(token))
+	if err != nil {
+		//Expired, Used or Unknown Link
+		return nil, utils.GenerateError(ErrInvalidMagicLink, err)
+	}
+
+	if isLockedOut(user, time.Now()) {
+		return nil, utils.GenerateError(ErrAccountLocked, errors.New("magic link sign-in attempted while locked"))
+	}
+
+	return uc.CompleteSignIn(user, client, config)
+}
+
+func (uc AuthService) VerifyMFA(input *models.MFALoginInput, client *models.ClientInfo, config *config.Config) (string, string, error) {
+	challenge := utils.HashOneTimeToken(input.MFAToken)
+	userID, err := uc.TokenRepo.FindMFAChallenge(challenge)
+	if err != nil {
+		//Expired, Used or Unknown Challenge
+		if errors.Is(err, repos.ErrMFAChallengeNotFound) {
+			return "", "", utils.GenerateError(ErrInvalidMFAToken, err)
+		}
+		return "", "", utils.GenerateError(ErrReadingTokenState, err)
+	}
+
+	user, err := uc.UserRepo.FindUserByID(userID)
+	if err != nil {
+		//User Not Found
+		return "", "", utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	if isLockedOut(user, time.Now()) {
+		//Locked or Within Progressive Delay
+		return "", "", utils.GenerateError(ErrAccountLocked, errors.New("mfa attempted while locked"))
+	}
+
+	if err := verifySecondFactor(uc.UserRepo, user, input.Code); err != nil {
+		//Wrong Code, Challenge Discarded After Too Many Attempts
+		if failErr := uc.TokenRepo.FailMFAChallenge(challenge, mfaChallengeMaxAttempts); failErr != nil {
+			return "", "", utils.GenerateError(ErrStoringToken, failErr)
+		}
+		//Counted Against the Account, so New Challenges Do Not Reset It
+		return "", "", recordFailedLogin(uc.UserRepo, user, config, ErrInvalidMFACode, err)
+	}
+
+	if err := uc.TokenRepo.DeleteMFAChallenge(challenge); err != nil {
+		return "", "", utils.GenerateError(ErrStoringToken, err)
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := uc.UserRepo.ResetFailedLogins(user.ID.Hex()); err != nil {
+			return "", "", utils.GenerateError(ErrGeneratingToken, err)
+		}
+	}
+
+	return uc.IssueTokens(user, client, config)
+}
+
+func (uc AuthService) UnlockAccount(unlockToken string) error {
+	err := uc.UserRepo.UnlockUser(utils.HashOneTimeToken(unlockToken))
+	if err != nil {
+		if errors.Is(err, repos.ErrUserNotFound) {
+			return utils.GenerateError(ErrUnlockTokenNotFound, err)
+		}
+		return utils.GenerateError(ErrUnlockingAccount, err)
+	}
+	return nil
+}
+
+// Counts a failed password or second factor and locks the account once the
+// configured threshold is reached, notifying the owner with an unlock link.
+// Below the threshold the error wraps failure.
+func recordFailedLogin(userRepo repos.IUserRepo, user *models.DBResponse, config *config.Config, failure error, origin error) error {
+	now := time.Now()
+	user, err := userRepo.RecordFailedLogin(user.ID.Hex(), now.Add(-config.LoginLockoutDuration))
+	if err != nil {
+		return utils.GenerateError(failure, err)
+	}
+
+	if user.FailedLoginAttempts < config.LoginMaxAttempts {
+		return utils.GenerateError(failure, origin)
+	}
+
+	unlockToken, unlockDigest := utils.NewOneTimeToken(20)
+	err = userRepo.LockUser(user.ID.Hex(), now.Add(config.LoginLockoutDuration), unlockDigest)
+	if err != nil {
+		return utils.GenerateError(failure, err)
+	}
+
+	// Send Email
+	emailData := utils.EmailData{
+		URL:       config.Origin + "/unlockaccount/" + unlockToken,
+		FirstName: getFirstName(user.Name),
+		Subject:   "Your account has been temporarily locked",
+	}
+
+	err = utils.SendEmail(user, &emailData, "accountLocked.html")
+	if err != nil {
+		//Locked, but Owner Not Notified
+		return utils.GenerateError(ErrAccountLocked, utils.GenerateError(ErrSendingEmail, err))
+	}
+
+	return utils.GenerateError(ErrAccountLocked, origin)
+}
+
+// Applies the progressive delay and lockout of recordFailedLogin to an email
+// without an account, so a locked response does not reveal that one exists.
+// Below the threshold the error wraps ErrUserNotFound.
+func (uc AuthService) recordUnknownSignIn(email string, config *config.Config, origin error) error {
+	now := time.Now()
+	failures, err := uc.TokenRepo.FindUnknownSignIns(email)
+	if err != nil {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	phantom := &models.DBResponse{FailedLoginAttempts: failures.Attempts, LastFailedLoginAt: failures.LastFailedAt, LockedUntil: failures.LockedUntil}
+	if isLockedOut(phantom, now) {
+		return utils.GenerateError(ErrAccountLocked, errors.New("sign-in attempted while locked"))
+	}
+
+	attempts, err := uc.TokenRepo.RecordUnknownSignIn(email, config.LoginLockoutDuration)
+	if err != nil {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	if attempts < config.LoginMaxAttempts {
+		return utils.GenerateError(ErrUserNotFound, origin)
+	}
+
+	if err := uc.TokenRepo.LockUnknownSignIns(email, now.Add(config.LoginLockoutDuration)); err != nil {
+		return utils.GenerateError(ErrUserNotFound, err)
+	}
+	return utils.GenerateError(ErrAccountLocked, origin)
+}
+
+// Rejects accounts an administrator suspended or the owner deleted.
+func checkAccountStatus(user *models.DBResponse) error {
+	switch user.Status {
+	case models.StatusSuspended:
+		return utils.GenerateError(ErrAccountSuspended, errors.New("account suspended: "+user.StatusReason))
+	case models.StatusDeleted:
+		return utils.GenerateError(ErrAccountDeleted, errors.New("account pending deletion"))
+	}
+	return nil
+}
+
+// Reports whether the account is locked or the progressive delay after the
+// last failed sign-in (1s, 2s, 4s, ... up to a minute) has not yet elapsed.
+func isLockedOut(user *models.DBResponse, now time.Time) bool {
+	if user.LockedUntil.After(now) {
+		return true
+	}
+
+	if user.FailedLoginAttempts < 2 {
+		return false
+	}
+
+	delay := time.Minute
+	if user.FailedLoginAttempts < 8 {
+		delay = time.Second << (user.FailedLoginAttempts - 2)
+	}
+
+	return user.LastFailedLoginAt.Add(delay).After(now)
+}
+
+func (uc AuthService) RefreshAccessToken(refresh_token string, config *config.Config) (string, string, error) {
+	claims, err := utils.ValidateToken(refresh_token, config.RefreshTokenKeys, config.TokenIssuer, config.RefreshTokenAudience)
+	if err != nil {
+		//Invalid Token
+		return "", "", utils.GenerateError(ErrInvalidRefreshToken, err)
+	}
+
+	jti, family := claims.ID, claims.Family
+	if family == "" {
+		//Token Issued Before Rotation
+		return "", "", utils.GenerateError(ErrInvalidRefreshToken, errors.New("missing family claim"))
+	}
+
+	user, err := uc.UserRepo.FindUserByID(claims.Subject)
+	if err != nil {
+		//User Not Found
+		return "", "", utils.GenerateError(ErrUserNotFound, err)
+	}
+
+	if err := checkAccountStatus(user); err != nil {
+		//Suspended or Deleted Since the Token Was Issued
+		return "", "", err
+	}
+
+	generation, err := uc.checkRevocation(claims)
+	if err != nil {
+		//Denylisted or Logged Out of All Devices
+		return "", "", err
+	}
+
+	access_token, err := createAccessToken(user, family, generation, config)
+	if err != nil {
+		//Failed to Create Token
+		return "", "", utils.GenerateError(ErrGeneratingToken, err)
+	}
+
+	new_refresh_token, newJti, err := createRefreshToken(user, family, generation, config)
+	if err != nil {
+		//Failed to Create Token
+		return "", "", utils.GenerateError(ErrGeneratingToken, err)
+	}
+
+	err = uc.TokenRepo.RotateRefreshToken(family, jti, newJti, config.RefreshTokenExpiresIn)
+	if err != nil {
+		//Already Rotated Token Presented, Family Revoked
+		if errors.Is(err, repos.ErrRefreshTokenReused) {
+			uc.SessionRepo.DeleteSessionByFamily(family)
+			return "", "", utils.GenerateError(ErrRefreshTokenReused, err)
+		}
+		//Family Revoked or Expired
+		if errors.Is(err, repos.ErrRefreshFamilyNotFound) {
+			return "", "", utils.GenerateError(ErrInvalidRefreshToken, err)
+		}
+		return "", "", utils.GenerateError(ErrStoringToken, err)
+	}
+
+	now := time.Now()
+	err = uc.SessionRepo.TouchSession(family, now, now.Add(config.RefreshTokenExpiresIn))
+	if err != nil {
+		//Session Revoked
+		return "", "", utils.GenerateError(ErrInvalidRefreshToken, err)
+	}
+
+	return access_token, new_refresh_token, nil
+}
+
+func (uc AuthService) ValidateAccessToken(access_token string, config *config.Config) (*models.TokenClaims, error) {
+	claims, err := utils.ValidateToken(access_token, config.AccessTokenKeys, config.TokenIssuer, config.AccessTokenAudience)
+	if err != nil {
+		//Invalid Token
+		return nil, utils.GenerateError(ErrInvalidAccessToken, err)
+	}
+
+	if _, err := uc.checkRevocation(claims); err != nil {
+		//Denylisted or Logged Out of All Devices
+		return nil, err
+	}
+
+	//Session Revoked
+	if claims.Family != "" {
+		exists, err := uc.TokenRepo.RefreshFamilyExists(claims.Family)
+		if err != nil {
+			return nil, utils.GenerateError(ErrReadingTokenState, err)
+		}
+		if !exists {
+			return nil, utils.GenerateError(ErrTokenRevoked, errors.New("session has been revoked"))
+		}
+	}
+
+	return claims, nil
+}
+
+func (uc AuthService) LogoutUser(access_token string, refresh_token string, config *config.Config) error {
+	//Access Token
+	if claims, err := utils.ValidateToken(access_token, config.AccessTokenKeys, config.TokenIssuer, config.AccessTokenAudience); err == nil {
+		if err := uc.denylistToken(claims); err != nil {
+			return utils.GenerateError(ErrRevokingToken, err)
+		}
+	}
+
+	//Refresh Token
+	if claims, err := utils.ValidateToken(refresh_token, config.RefreshTokenKeys, config.TokenIssuer, config.RefreshTokenAudience); err == nil {
+		if err := uc.denylistToken(claims); err != nil {
+			return utils.GenerateError(ErrRevokingToken, err)
+		}
+		if claims.Family != "" {
+			if err := uc.TokenRepo.RevokeRefreshFamily(claims.Family); err != nil {
+				return utils.GenerateError(ErrRevokingToken, err)
+			}
+			if err := uc.SessionRepo.DeleteSessionByFamily(claims.Family); err != nil {
+				return utils.GenerateError(ErrRevokingToken, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (uc AuthService) LogoutAllDevices(userID string) error {
+	_, err := uc.TokenRepo.IncrementTokenGeneration(userID)
+	if err != nil {
+		//Failed to Bump Generation
+		return utils.GenerateError(ErrRevokingToken, err)
+	}
+
+	sessions, err := uc.SessionRepo.DeleteSessionsByUserID(userID)
+	if err != nil {
+		//Failed to Delete Sessions
+		return utils.GenerateError(ErrRevokingToken, err)
+	}
+
+	for _, session := range sessions {
+		if err := uc.TokenRepo.RevokeRefreshFamily(session.Family); err != nil {
+			return utils.GenerateError(ErrRevokingToken, err)
+		}
+	}
+
+	return nil
+}
+
+// Starts a new session for the user and issues its access and refresh tokens.
+func (uc AuthService) IssueTokens(user *models.DBResponse, client *models.ClientInfo, config *config.Config) (string, string, error) {
+	if err := checkAccountStatus(user); err != nil {
+		return "", "", err
+	}
+
+	generation, err := uc.TokenRepo.GetTokenGeneration(user.ID.Hex())
+	if err != nil {
+		//Failed to Read Token Generation
+		return "", "", utils.GenerateError(ErrGeneratingToken, err)
 	}
 
 	// Generate Tokens
-	access_token, err := utils.CreateToken(config.AccessTokenExpiresIn, user.ID, config.AccessTokenPrivateKey)
+	family := randstr.Hex(32)
+	access_token, err := createAccessToken(user, family, generation, config)
 	if err != nil {
 		//Failed to Generate Tokens
 		return "", "", utils.GenerateError(ErrGeneratingToken, err)
 	}
 
-	refresh_token, err := utils.CreateToken(config.RefreshTokenExpiresIn, user.ID, config.RefreshTokenPrivateKey)
+	refresh_token, jti, err := createRefreshToken(user, family, generation, config)
 	if err != nil {
 		//Failed to Generate Tokens
 		return "", "", utils.GenerateError(ErrGeneratingToken, err)
 	}
 
+	// Start Refresh Token Family
+	err = uc.TokenRepo.CreateRefreshFamily(family, jti, config.RefreshTokenExpiresIn)
+	if err != nil {
+		//Failed to Store Refresh Token
+		return "", "", utils.GenerateError(ErrStoringToken, err)
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		UserID:     user.ID,
+		Family:     family,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(config.RefreshTokenExpiresIn),
+	}
+
+	_, err = uc.SessionRepo.CreateSession(session)
+	if err != nil {
+		//Failed to Store Session
+		return "", "", utils.GenerateError(ErrStoringToken, err)
+	}
+
 	return access_token, refresh_token, nil
 }
 
-func (uc AuthService) RefreshAccessToken(refresh_token string, config *config.Config) (string, error) {
-	sub, err := utils.ValidateToken(refresh_token, config.RefreshTokenPublicKey)
+func (uc AuthService) denylistToken(claims *models.TokenClaims) error {
+	return uc.TokenRepo.DenylistToken(claims.ID, time.Until(claims.ExpiresAt.Time))
+}
+
+// Rejects tokens whose jti is denylisted or that were issued before the
+// user's current token generation. Returns the current generation.
+func (uc AuthService) checkRevocation(claims *models.TokenClaims) (int64, error) {
+	denylisted, err := uc.TokenRepo.IsTokenDenylisted(claims.ID)
 	if err != nil {
-		//Invalid Token
-		return "", utils.GenerateError(ErrInvalidRefreshToken, err)
+		return 0, utils.GenerateError(ErrReadingTokenState, err)
+	}
+	if denylisted {
+		return 0, utils.GenerateError(ErrTokenRevoked, errors.New("token jti is denylisted"))
 	}
 
-	user, err := uc.UserRepo.FindUserByID(fmt.Sprint(sub))
+	generation, err := uc.TokenRepo.GetTokenGeneration(claims.Subject)
 	if err != nil {
-		//User Not Found
-		return "", utils.GenerateError(ErrUserNotFound, err)
+		return 0, utils.GenerateError(ErrReadingTokenState, err)
 	}
 
-	access_token, err := utils.CreateToken(config.AccessTokenExpiresIn, user.ID, config.AccessTokenPrivateKey)
-	if err != nil {
-		//Failed to Create Token
-		return "", utils.GenerateError(ErrGeneratingToken, err)
+	if claims.Generation < generation {
+		return 0, utils.GenerateError(ErrTokenRevoked, errors.New("token generation is outdated"))
 	}
 
-	return access_token, nil
+	return generation, nil
+}
+
+// Access tokens carry the role and scopes so requests can be authorized
+// without loading the user.
+func createAccessToken(user *models.DBResponse, family string, generation int64, config *config.Config) (string, error) {
+	return utils.CreateToken(config.AccessTokenExpiresIn, &models.TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   config.TokenIssuer,
+			Subject:  user.ID.Hex(),
+			Audience: jwt.ClaimStrings{config.AccessTokenAudience},
+		},
+		Role:       user.Role,
+		Scope:      models.ScopeList{models.ScopeRead, models.ScopeWrite},
+		Family:     family,
+		Generation: generation,
+	}, config.AccessTokenKeys)
+}
+
+func createRefreshToken(user *models.DBResponse, family string, generation int64, config *config.Config) (string, string, error) {
+	jti := randstr.Hex(32)
+	token, err := utils.CreateToken(config.RefreshTokenExpiresIn, &models.TokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   config.TokenIssuer,
+			Subject:  user.ID.Hex(),
+			Audience: jwt.ClaimStrings{config.RefreshTokenAudience},
+			ID:       jti,
+		},
+		Family:     family,
+		Generation: generation,
+	}, config.RefreshTokenKeys)
+	if err != nil {
+		return "", "", err
+	}
+	return token, jti, nil
 }
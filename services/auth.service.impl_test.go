@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+)
+
+type fakeSignInUserRepo struct {
+	repos.IUserRepo
+	user *models.DBResponse
+}
+
+func (fr *fakeSignInUserRepo) FindUserByEmail(email string) (*models.DBResponse, error) {
+	if fr.user == nil || fr.user.Email != email {
+		return nil, repos.ErrUserNotFound
+	}
+	user := *fr.user
+	return &user, nil
+}
+
+func (fr *fakeSignInUserRepo) RecordFailedLogin(id string, since time.Time) (*models.DBResponse, error) {
+	fr.user.FailedLoginAttempts++
+	fr.user.LastFailedLoginAt = time.Now()
+	user := *fr.user
+	return &user, nil
+}
+
+// Keeps failed sign-ins for unknown emails in memory.
+type fakeSignInTokenRepo struct {
+	repos.ITokenRepo
+	unknown map[string]*models.UnknownSignIns
+}
+
+func (fr *fakeSignInTokenRepo) FindUnknownSignIns(email string) (*models.UnknownSignIns, error) {
+	if failures, ok := fr.unknown[email]; ok {
+		copied := *failures
+		return &copied, nil
+	}
+	return &models.UnknownSignIns{}, nil
+}
+
+func (fr *fakeSignInTokenRepo) RecordUnknownSignIn(email string, window time.Duration) (int, error) {
+	failures, ok := fr.unknown[email]
+	if !ok {
+		failures = &models.UnknownSignIns{}
+		fr.unknown[email] = failures
+	}
+	failures.Attempts++
+	failures.LastFailedAt = time.Now()
+	return failures.Attempts, nil
+}
+
+// Records the hashes it was asked to verify against.
+type recordingHasher struct {
+	IPasswordHasher
+	verified []string
+}
+
+func (rh *recordingHasher) Verify(hashedPassword string, candidatePassword string) error {
+	rh.verified = append(rh.verified, hashedPassword)
+	return rh.IPasswordHasher.Verify(hashedPassword, candidatePassword)
+}
+
+func TestSignInUserHashesForUnknownEmail(t *testing.T) {
+	hasher, err := NewPasswordHasher(argon2Config(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &recordingHasher{IPasswordHasher: hasher}
+	tokenRepo := &fakeSignInTokenRepo{unknown: map[string]*models.UnknownSignIns{}}
+	service := NewAuthService(&fakeSignInUserRepo{}, tokenRepo, nil, nil, recorder, context.Background())
+
+	_, err = service.SignInUser(&models.SignInInput{Email: "nobody@example.com", Password: "password"}, &models.ClientInfo{}, &config.Config{LoginMaxAttempts: 5, LoginLockoutDuration: time.Minute})
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("err = %v, want %v", err, ErrUserNotFound)
+	}
+
+	if len(recorder.verified) != 1 || recorder.verified[0] == "" {
+		t.Fatalf("verified against %q, want one real dummy hash", recorder.verified)
+	}
+}
+
+func TestSignInLockoutDoesNotRevealAccounts(t *testing.T) {
+	hasher, err := NewPasswordHasher(argon2Config(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := &fakeSignInUserRepo{user: &models.DBResponse{Email: "jane@example.com", Password: hash, Verified: true}}
+	tokenRepo := &fakeSignInTokenRepo{unknown: map[string]*models.UnknownSignIns{}}
+	service := NewAuthService(userRepo, tokenRepo, nil, nil, hasher, context.Background())
+	config := &config.Config{LoginMaxAttempts: 5, LoginLockoutDuration: time.Minute}
+
+	//Two Failures, Then the Progressive Delay Applies to Both
+	for _, email := range []string{"jane@example.com", "nobody@example.com"} {
+		for attempt := 1; attempt <= 3; attempt++ {
+			_, err := service.SignInUser(&models.SignInInput{Email: email, Password: "wrong password"}, &models.ClientInfo{}, config)
+			locked := errors.Is(err, ErrAccountLocked)
+			if wantLocked := attempt == 3; locked != wantLocked {
+				t.Fatalf("%s attempt %d: err = %v, want locked %v", email, attempt, err, wantLocked)
+			}
+		}
+	}
+
+	if userRepo.user.FailedLoginAttempts != 2 || tokenRepo.unknown["nobody@example.com"].Attempts != 2 {
+		t.Fatalf("attempts = %d and %d, want 2 each", userRepo.user.FailedLoginAttempts, tokenRepo.unknown["nobody@example.com"].Attempts)
+	}
+}
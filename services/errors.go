@@ -17,4 +17,56 @@ var (
 	ErrHashingPassword        = errors.New("failed to hash password")
 	ErrResetTokenNotFound     = errors.New("failed to find user with reset token")
 	ErrUpdatingPassword       = errors.New("failed to update password")
+	ErrRefreshTokenReused     = errors.New("refresh token reuse detected")
+	ErrStoringToken           = errors.New("failed to store token")
+	ErrInvalidAccessToken     = errors.New("invalid access token")
+	ErrTokenRevoked           = errors.New("token has been revoked")
+	ErrRevokingToken          = errors.New("failed to revoke token")
+	ErrReadingTokenState      = errors.New("failed to read token state")
+	ErrFindingSessions        = errors.New("failed to find sessions")
+	ErrSessionNotFound        = errors.New("failed to find session")
+	ErrAccountLocked          = errors.New("account temporarily locked")
+	ErrUnlockTokenNotFound    = errors.New("failed to find user with unlock token")
+	ErrUnlockingAccount       = errors.New("failed to unlock account")
+	ErrInvalidMFAToken        = errors.New("invalid or expired mfa token")
+	ErrInvalidMFACode         = errors.New("invalid mfa code")
+	ErrMFAAlreadyEnabled      = errors.New("two-factor authentication already enabled")
+	ErrMFANotEnrolled         = errors.New("two-factor authentication enrollment not started")
+	ErrMFANotEnabled          = errors.New("two-factor authentication not enabled")
+	ErrUpdatingMFA            = errors.New("failed to update two-factor authentication")
+	ErrWebAuthnCeremony       = errors.New("webauthn ceremony failed")
+	ErrWebAuthnSessionExpired = errors.New("webauthn session expired or not found")
+	ErrStoringCredential      = errors.New("failed to store credential")
+	ErrFindingCredentials     = errors.New("failed to find credentials")
+	ErrCredentialNotFound     = errors.New("failed to find credential")
+	ErrOAuthProviderNotFound  = errors.New("oauth provider not configured")
+	ErrOAuthStateInvalid      = errors.New("invalid or expired oauth state")
+	ErrOAuthExchange          = errors.New("failed to exchange oauth code")
+	ErrOAuthEmailNotVerified  = errors.New("oauth provider did not return a verified email")
+	ErrLinkingIdentity        = errors.New("failed to link external identity")
+	ErrIdentityConflict       = errors.New("another identity from the provider is already linked")
+	ErrInvalidMagicLink       = errors.New("invalid or expired magic link")
+	ErrInvalidAPIKey          = errors.New("invalid or expired api key")
+	ErrInvalidAPIKeyInput     = errors.New("invalid api key input")
+	ErrStoringAPIKey          = errors.New("failed to store api key")
+	ErrFindingAPIKeys         = errors.New("failed to find api keys")
+	ErrAPIKeyNotFound         = errors.New("failed to find api key")
+	ErrSyncingSigningKeys     = errors.New("failed to sync signing keys")
+	ErrRotatingSigningKey     = errors.New("failed to rotate signing key")
+	ErrInvalidRole            = errors.New("invalid role")
+	ErrBootstrappingAdmin     = errors.New("failed to bootstrap admin")
+	ErrFindingUsers           = errors.New("failed to find users")
+	ErrDeletingUser           = errors.New("failed to delete user")
+	ErrRestoreTokenNotFound   = errors.New("failed to find user with restore token")
+	ErrBuildingExport         = errors.New("failed to build data export")
+	ErrStoringExport          = errors.New("failed to store data export")
+	ErrExportNotFound         = errors.New("failed to find data export")
+	ErrWeakPassword           = errors.New("password does not meet policy")
+	ErrPasswordUnchanged      = errors.New("new password matches the current password")
+	ErrInvalidProfileInput    = errors.New("invalid profile input")
+	ErrEmailUnchanged         = errors.New("new email matches the current email")
+	ErrEmailInUse             = errors.New("email already in use")
+	ErrEmailChangeNotFound    = errors.New("failed to find user with email change token")
+	ErrAccountSuspended       = errors.New("account suspended")
+	ErrAccountDeleted         = errors.New("account deleted")
 )
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+type IExportService interface {
+	RequestExport(user *models.DBResponse, config *config.Config)
+	BuildExport(user *models.DBResponse) ([]byte, error)
+	FindExport(token string, userID string) (*models.DataExport, error)
+}
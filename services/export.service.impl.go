@@ -0,0 +1,156 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"log"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+const exportArchiveTemplate = "templates/dataExportArchive.html"
+
+// Builds data exports in the background and emails the owner a link to
+// download them.
+type ExportService struct {
+	userRepo       repos.IUserRepo
+	sessionRepo    repos.ISessionRepo
+	credentialRepo repos.ICredentialRepo
+	apiKeyRepo     repos.IAPIKeyRepo
+	exportRepo     repos.IExportRepo
+	ctx            context.Context
+}
+
+func NewExportService(userRepo repos.IUserRepo, sessionRepo repos.ISessionRepo, credentialRepo repos.ICredentialRepo, apiKeyRepo repos.IAPIKeyRepo, exportRepo repos.IExportRepo, ctx context.Context) IExportService {
+	return &ExportService{userRepo, sessionRepo, credentialRepo, apiKeyRepo, exportRepo, ctx}
+}
+
+func (es ExportService) RequestExport(user *models.DBResponse, config *config.Config) {
+	go func() {
+		if err := es.createExport(user, config); err != nil {
+			log.Println("data export:", err)
+		}
+	}()
+}
+
+func (es ExportService) createExport(user *models.DBResponse, config *config.Config) error {
+	archive, err := es.BuildExport(user)
+	if err != nil {
+		return err
+	}
+
+	token, digest := utils.NewOneTimeToken(32)
+	now := time.Now()
+	_, err = es.exportRepo.CreateExport(&models.DataExport{
+		UserID:    user.ID,
+		Token:     digest,
+		Archive:   archive,
+		CreatedAt: now,
+		ExpiresAt: now.Add(config.DataExportExpiresIn),
+	})
+	if err != nil {
+		return utils.GenerateError(ErrStoringExport, err)
+	}
+
+	// Send Email
+	emailData := utils.EmailData{
+		URL:       config.Origin + "/account/export/" + token,
+		FirstName: getFirstName(user.Name),
+		Subject:   "Your data export is ready",
+	}
+
+	err = utils.SendEmail(user, &emailData, "dataExportReady.html")
+	if err != nil {
+		return utils.GenerateError(ErrSendingEmail, err)
+	}
+
+	return nil
+}
+
+// Collects everything stored about the user into a zip holding the data as
+// JSON and a readable HTML page.
+func (es ExportService) BuildExport(user *models.DBResponse) ([]byte, error) {
+	//Reload, the Caller's Copy May Be Stale
+	user, err := es.userRepo.FindUserByID(user.ID.Hex())
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserIDNotFound, err)
+	}
+
+	userID := user.ID.Hex()
+	sessions, err := es.sessionRepo.FindSessionsByUserID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+
+	credentials, err := es.credentialRepo.FindCredentialsByUserID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+
+	apiKeys, err := es.apiKeyRepo.FindAPIKeysByUserID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+
+	data := &models.UserDataExport{
+		GeneratedAt: time.Now().UTC(),
+		Profile:     models.ExportProfile(user),
+		Identities:  user.Identities,
+		Sessions:    sessions,
+		Credentials: credentials,
+		APIKeys:     apiKeys,
+	}
+
+	dataJSON, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+
+	page, err := template.ParseFiles(exportArchiveTemplate)
+	if err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+	var dataHTML bytes.Buffer
+	if err := page.Execute(&dataHTML, data); err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	files := []struct {
+		name string
+		body []byte
+	}{
+		{"data.json", dataJSON},
+		{"index.html", dataHTML.Bytes()},
+	}
+	for _, file := range files {
+		f, err := writer.Create(file.name)
+		if err != nil {
+			return nil, utils.GenerateError(ErrBuildingExport, err)
+		}
+		if _, err := f.Write(file.body); err != nil {
+			return nil, utils.GenerateError(ErrBuildingExport, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, utils.GenerateError(ErrBuildingExport, err)
+	}
+
+	return archive.Bytes(), nil
+}
+
+func (es ExportService) FindExport(token string, userID string) (*models.DataExport, error) {
+	export, err := es.exportRepo.FindExportByToken(utils.HashOneTimeToken(token), userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrExportNotFound, err)
+	}
+	return export, nil
+}
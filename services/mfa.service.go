@@ -0,0 +1,12 @@
+package services
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+type IMFAService interface {
+	EnrollTOTP(user *models.DBResponse, config *config.Config) (*models.MFAEnrollment, error)
+	ConfirmTOTP(user *models.DBResponse, code string) ([]string, error)
+	DisableTOTP(user *models.DBResponse, input *models.MFADisableInput, config *config.Config) error
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+const recoveryCodeCount = 10
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+type MFAService struct {
+	userRepo repos.IUserRepo
+	hasher   IPasswordHasher
+	ctx      context.Context
+}
+
+func NewMFAService(userRepo repos.IUserRepo, hasher IPasswordHasher, ctx context.Context) IMFAService {
+	return &MFAService{userRepo, hasher, ctx}
+}
+
+func (ms MFAService) EnrollTOTP(user *models.DBResponse, config *config.Config) (*models.MFAEnrollment, error) {
+	if user.TOTPEnabled {
+		return nil, utils.GenerateError(ErrMFAAlreadyEnabled, errors.New("enrollment requested while enabled"))
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	err := ms.userRepo.StorePendingTOTPSecret(user.ID.Hex(), secret)
+	if err != nil {
+		//Failed to Store Secret
+		return nil, utils.GenerateError(ErrUpdatingMFA, err)
+	}
+
+	return &models.MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+func (ms MFAService) ConfirmTOTP(user *models.DBResponse, code string) ([]string, error) {
+	if user.TOTPEnabled {
+		return nil, utils.GenerateError(ErrMFAAlreadyEnabled, errors.New("confirmation requested while enabled"))
+	}
+
+	if user.TOTPPendingSecret == "" {
+		return nil, utils.GenerateError(ErrMFANotEnrolled, errors.New("no pending secret"))
+	}
+
+	if _, ok := utils.ValidateTOTP(user.TOTPPendingSecret, code, time.Now()); !ok {
+		return nil, utils.GenerateError(ErrInvalidMFACode, errors.New("code does not match pending secret"))
+	}
+
+	// Generate Recovery Codes, Only Hashes Are Stored
+	recoveryCodes := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	hashedCodes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashedCodes[i] = utils.HashRecoveryCode(code)
+	}
+
+	err := ms.userRepo.EnableTOTP(user.ID.Hex(), user.TOTPPendingSecret, hashedCodes)
+	if err != nil {
+		//Failed to Enable
+		return nil, utils.GenerateError(ErrUpdatingMFA, err)
+	}
+
+	return recoveryCodes, nil
+}
+
+// Needs the account password or, for accounts without one they know, a
+// current second factor. Failures count toward the sign-in lockout.
+func (ms MFAService) DisableTOTP(user *models.DBResponse, input *models.MFADisableInput, config *config.Config) error {
+	if !user.TOTPEnabled {
+		return utils.GenerateError(ErrMFANotEnabled, errors.New("disable requested while not enabled"))
+	}
+
+	if isLockedOut(user, time.Now()) {
+		//Locked or Within Progressive Delay
+		return utils.GenerateError(ErrAccountLocked, errors.New("mfa disable attempted while locked"))
+	}
+
+	if input.Code != "" {
+		if err := verifySecondFactor(ms.userRepo, user, input.Code); err != nil {
+			//Wrong Code
+			return recordFailedLogin(ms.userRepo, user, config, ErrInvalidMFACode, err)
+		}
+	} else if err := ms.hasher.Verify(user.Password, input.Password); err != nil {
+		//Incorrect Password
+		return recordFailedLogin(ms.userRepo, user, config, ErrIncorrectPassword, err)
+	}
+
+	err := ms.userRepo.DisableTOTP(user.ID.Hex())
+	if err != nil {
+		//Failed to Disable
+		return utils.GenerateError(ErrUpdatingMFA, err)
+	}
+
+	if user.FailedLoginAttempts > 0 {
+		if err := ms.userRepo.ResetFailedLogins(user.ID.Hex()); err != nil {
+			return utils.GenerateError(ErrUpdatingMFA, err)
+		}
+	}
+
+	return nil
+}
+
+// Accepts either a current TOTP code or an unused recovery code, consuming it.
+func verifySecondFactor(userRepo repos.IUserRepo, user *models.DBResponse, code string) error {
+	if totpCodePattern.MatchString(code) {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return utils.GenerateError(ErrInvalidMFACode, errors.New("totp code does not match"))
+		}
+
+		//Reject Replayed Codes
+		if err := userRepo.UseTOTPStep(user.ID.Hex(), step); err != nil {
+			return utils.GenerateError(ErrInvalidMFACode, err)
+		}
+		return nil
+	}
+
+	if err := userRepo.ConsumeRecoveryCode(user.ID.Hex(), utils.HashRecoveryCode(code)); err != nil {
+		return utils.GenerateError(ErrInvalidMFACode, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeMFAUserRepo struct {
+	repos.IUserRepo
+	user *models.DBResponse
+}
+
+func (fr *fakeMFAUserRepo) ConsumeRecoveryCode(id string, code string) error {
+	for i, stored := range fr.user.RecoveryCodes {
+		if stored == code {
+			fr.user.RecoveryCodes = append(fr.user.RecoveryCodes[:i], fr.user.RecoveryCodes[i+1:]...)
+			return nil
+		}
+	}
+	return repos.ErrUserNotFound
+}
+
+func (fr *fakeMFAUserRepo) RecordFailedLogin(id string, since time.Time) (*models.DBResponse, error) {
+	fr.user.FailedLoginAttempts++
+	fr.user.LastFailedLoginAt = time.Now()
+	user := *fr.user
+	return &user, nil
+}
+
+func (fr *fakeMFAUserRepo) ResetFailedLogins(id string) error {
+	fr.user.FailedLoginAttempts = 0
+	return nil
+}
+
+func (fr *fakeMFAUserRepo) DisableTOTP(id string) error {
+	fr.user.TOTPEnabled = false
+	return nil
+}
+
+func TestDisableTOTPWithRecoveryCode(t *testing.T) {
+	//Created Through a Passkey, so No Password the Owner Knows
+	user := &models.DBResponse{ID: primitive.NewObjectID(), TOTPEnabled: true, TOTPSecret: utils.GenerateTOTPSecret(), RecoveryCodes: []string{utils.HashRecoveryCode("abcd-efgh")}}
+	userRepo := &fakeMFAUserRepo{user: user}
+	service := NewMFAService(userRepo, nil, context.Background())
+	config := &config.Config{LoginMaxAttempts: 5, LoginLockoutDuration: time.Minute}
+
+	err := service.DisableTOTP(user, &models.MFADisableInput{Code: "wxyz-wxyz"}, config)
+	if !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidMFACode)
+	}
+	if user.FailedLoginAttempts != 1 || !user.TOTPEnabled {
+		t.Fatalf("attempts = %d, enabled = %v, want the failure counted and totp kept", user.FailedLoginAttempts, user.TOTPEnabled)
+	}
+
+	if err := service.DisableTOTP(user, &models.MFADisableInput{Code: "abcd-efgh"}, config); err != nil {
+		t.Fatalf("DisableTOTP: %v", err)
+	}
+	if user.TOTPEnabled || user.FailedLoginAttempts != 0 {
+		t.Fatalf("enabled = %v, attempts = %d, want disabled with failures cleared", user.TOTPEnabled, user.FailedLoginAttempts)
+	}
+}
@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+type IOAuthProvider interface {
+	AuthCodeURL(state string, nonce string, verifier string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (*models.ExternalIdentity, error)
+}
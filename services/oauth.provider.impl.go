@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Builds a provider for every integration with a client id configured, keyed by
+// the name used in the login and callback routes.
+func NewOAuthProviders(config *config.Config) map[string]IOAuthProvider {
+	providers := make(map[string]IOAuthProvider)
+	redirectURL := func(name string) string {
+		return strings.TrimSuffix(config.OAuthRedirectURL, "/") + "/" + name + "/callback"
+	}
+
+	if config.GoogleClientID != "" {
+		providers["google"] = NewOIDCProvider("google", config.GoogleIssuerURL, oauth2.Config{
+			ClientID:     config.GoogleClientID,
+			ClientSecret: config.GoogleClientSecret,
+			RedirectURL:  redirectURL("google"),
+		})
+	}
+
+	if config.GitHubClientID != "" {
+		providers["github"] = NewGitHubProvider(config.GitHubAPIURL, oauth2.Config{
+			ClientID:     config.GitHubClientID,
+			ClientSecret: config.GitHubClientSecret,
+			RedirectURL:  redirectURL("github"),
+			Endpoint:     oauth2.Endpoint{AuthURL: config.GitHubAuthURL, TokenURL: config.GitHubTokenURL},
+		})
+	}
+
+	if config.OIDCClientID != "" {
+		providers[config.OIDCProviderName] = NewOIDCProvider(config.OIDCProviderName, config.OIDCIssuerURL, oauth2.Config{
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			RedirectURL:  redirectURL(config.OIDCProviderName),
+		})
+	}
+
+	return providers
+}
+
+// Any OpenID Connect provider, configured through issuer discovery.
+type OIDCProvider struct {
+	name      string
+	issuerURL string
+	config    oauth2.Config
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCProvider(name string, issuerURL string, config oauth2.Config) *OIDCProvider {
+	config.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	return &OIDCProvider{name: name, issuerURL: issuerURL, config: config}
+}
+
+// Discovery runs on first use so an unreachable issuer does not prevent startup.
+func (op *OIDCProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	if op.provider != nil {
+		return op.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, op.issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", op.issuerURL, err)
+	}
+	op.provider = provider
+	op.config.Endpoint = provider.Endpoint()
+
+	return provider, nil
+}
+
+func (op *OIDCProvider) AuthCodeURL(state string, nonce string, verifier string) (string, error) {
+	if _, err := op.discover(context.Background()); err != nil {
+		return "", err
+	}
+	return op.config.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (op *OIDCProvider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*models.ExternalIdentity, error) {
+	provider, err := op.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := op.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("exchange: missing id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: op.config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+
+	if idToken.Nonce != nonce {
+		return nil, errors.New("verify id_token: nonce mismatch")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+
+	return &models.ExternalIdentity{
+		Provider:      op.name,
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// GitHub is plain OAuth2, so the identity is read from its REST API.
+type GitHubProvider struct {
+	apiURL string
+	config oauth2.Config
+}
+
+func NewGitHubProvider(apiURL string, config oauth2.Config) *GitHubProvider {
+	config.Scopes = []string{"read:user", "user:email"}
+	return &GitHubProvider{strings.TrimSuffix(apiURL, "/"), config}
+}
+
+func (gp *GitHubProvider) AuthCodeURL(state string, nonce string, verifier string) (string, error) {
+	return gp.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (gp *GitHubProvider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*models.ExternalIdentity, error) {
+	token, err := gp.config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange: %w", err)
+	}
+	client := gp.config.Client(ctx, token)
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := gp.get(client, "/user", &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := gp.get(client, "/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &models.ExternalIdentity{
+		Provider: "github",
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+	}
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
+
+func (gp *GitHubProvider) get(client *http.Client, path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, gp.apiURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("github %s: %w", path, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github %s: %w", path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("github %s: unexpected status %d", path, res.StatusCode)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return fmt.Errorf("github %s: %w", path, err)
+	}
+	return nil
+}
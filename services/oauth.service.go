@@ -0,0 +1,11 @@
+package services
+
+import (
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+type IOAuthService interface {
+	BeginLogin(provider string, config *config.Config) (string, string, error)
+	FinishLogin(provider string, state string, code string, config *config.Config) (*models.DBResponse, error)
+}
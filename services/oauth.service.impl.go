@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/thanhpk/randstr"
+	"golang.org/x/oauth2"
+)
+
+const oauthExchangeTimeout = 10 * time.Second
+
+type OAuthService struct {
+	userRepo  repos.IUserRepo
+	tokenRepo repos.ITokenRepo
+	providers map[string]IOAuthProvider
+	hasher    IPasswordHasher
+	ctx       context.Context
+}
+
+func NewOAuthService(userRepo repos.IUserRepo, tokenRepo repos.ITokenRepo, providers map[string]IOAuthProvider, hasher IPasswordHasher, ctx context.Context) IOAuthService {
+	return &OAuthService{userRepo, tokenRepo, providers, hasher, ctx}
+}
+
+// Returns the provider's authorization URL along with the state that must
+// come back on the callback.
+func (oa OAuthService) BeginLogin(providerName string, config *config.Config) (string, string, error) {
+	provider, ok := oa.providers[providerName]
+	if !ok {
+		return "", "", ErrOAuthProviderNotFound
+	}
+
+	state := randstr.Hex(32)
+	oauthState := models.OAuthState{
+		Provider: providerName,
+		Verifier: oauth2.GenerateVerifier(),
+		Nonce:    randstr.Hex(16),
+	}
+
+	authURL, err := provider.AuthCodeURL(state, oauthState.Nonce, oauthState.Verifier)
+	if err != nil {
+		return "", "", utils.GenerateError(ErrOAuthExchange, err)
+	}
+
+	data, err := json.Marshal(oauthState)
+	if err != nil {
+		return "", "", utils.GenerateError(ErrStoringToken, err)
+	}
+
+	err = oa.tokenRepo.StoreOAuthState(state, data, config.OAuthStateExpiresIn)
+	if err != nil {
+		return "", "", utils.GenerateError(ErrStoringToken, err)
+	}
+
+	return authURL, state, nil
+}
+
+func (oa OAuthService) FinishLogin(providerName string, state string, code string, config *config.Config) (*models.DBResponse, error) {
+	provider, ok := oa.providers[providerName]
+	if !ok {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	data, err := oa.tokenRepo.ConsumeOAuthState(state)
+	if err != nil {
+		//Expired, Used or Unknown State
+		if errors.Is(err, repos.ErrOAuthStateNotFound) {
+			return nil, utils.GenerateError(ErrOAuthStateInvalid, err)
+		}
+		return nil, utils.GenerateError(ErrReadingTokenState, err)
+	}
+
+	var oauthState models.OAuthState
+	if err := json.Unmarshal(data, &oauthState); err != nil {
+		return nil, utils.GenerateError(ErrOAuthStateInvalid, err)
+	}
+	if oauthState.Provider != providerName {
+		return nil, utils.GenerateError(ErrOAuthStateInvalid, errors.New("state issued for another provider"))
+	}
+
+	ctx, cancel := context.WithTimeout(oa.ctx, oauthExchangeTimeout)
+	defer cancel()
+
+	identity, err := provider.Exchange(ctx, code, oauthState.Verifier, oauthState.Nonce)
+	if err != nil {
+		return nil, utils.GenerateError(ErrOAuthExchange, err)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, utils.GenerateError(ErrOAuthEmailNotVerified, errors.New(providerName+" returned no verified email"))
+	}
+
+	user, err := oa.resolveUser(identity, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if isLockedOut(user, time.Now()) {
+		return nil, utils.GenerateError(ErrAccountLocked, errors.New("oauth sign-in attempted while locked"))
+	}
+
+	return user, nil
+}
+
+// Finds the user already linked to the identity, otherwise links the user with
+// the same verified email, otherwise creates a new verified user. Accounts
+// verified here are promoted like those verifying their email.
+func (oa OAuthService) resolveUser(identity *models.ExternalIdentity, config *config.Config) (*models.DBResponse, error) {
+	user, err := oa.userRepo.FindUserByIdentity(identity.Provider, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, repos.ErrUserNotFound) {
+		return nil, utils.GenerateError(ErrLinkingIdentity, err)
+	}
+
+	email := strings.ToLower(identity.Email)
+	user, err = oa.userRepo.FindUserByEmail(email)
+	if err != nil {
+		if !errors.Is(err, repos.ErrUserNotFound) {
+			return nil, utils.GenerateError(ErrLinkingIdentity, err)
+		}
+		userID, err := oa.createUser(identity, email)
+		if err != nil {
+			return nil, err
+		}
+		return oa.linkVerifiedIdentity(userID, identity, email, config)
+	}
+
+	if user.Verified {
+		return oa.linkIdentity(user.ID.Hex(), identity, email)
+	}
+
+	//Unverified Password Set by Whoever Registered the Email First
+	password, err := oa.hasher.Hash(randstr.Hex(32))
+	if err != nil {
+		return nil, utils.GenerateError(ErrHashingPassword, err)
+	}
+	err = oa.userRepo.UpdateUserById(user.ID.Hex(), &models.UpdateInput{Password: password, Verified: true, UpdatedAt: time.Now()})
+	if err != nil {
+		return nil, utils.GenerateError(ErrLinkingIdentity, err)
+	}
+
+	return oa.linkVerifiedIdentity(user.ID.Hex(), identity, email, config)
+}
+
+// New users get an unusable random password and skip email verification,
+// the provider having already verified the address.
+func (oa OAuthService) createUser(identity *models.ExternalIdentity, email string) (string, error) {
+	password, err := oa.hasher.Hash(randstr.Hex(32))
+	if err != nil {
+		return "", utils.GenerateError(ErrHashingPassword, err)
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+
+	now := time.Now()
+	userID, err := oa.userRepo.CreateNewUser(&models.SignUpInput{
+		Name:      name,
+		Email:     email,
+		Password:  password,
+		Role:      models.RoleUser,
+		Status:    models.StatusActive,
+		Verified:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return "", utils.GenerateError(ErrCreatingUser, err)
+	}
+
+	return userID, nil
+}
+
+// Links an account this sign-in just verified and promotes it if configured.
+func (oa OAuthService) linkVerifiedIdentity(userID string, identity *models.ExternalIdentity, email string, config *config.Config) (*models.DBResponse, error) {
+	user, err := oa.linkIdentity(userID, identity, email)
+	if err != nil {
+		return nil, err
+	}
+
+	promoted, err := promoteBootstrapAdmin(oa.userRepo, user, config.AdminEmails)
+	if err != nil {
+		log.Println("Failed to promote admin:", err)
+		return user, nil
+	}
+
+	return promoted, nil
+}
+
+func (oa OAuthService) linkIdentity(userID string, identity *models.ExternalIdentity, email string) (*models.DBResponse, error) {
+	err := oa.userRepo.LinkIdentity(userID, &models.LinkedIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    email,
+		LinkedAt: time.Now(),
+	})
+	if err != nil {
+		//Another Account From the Same Provider Is Already Linked
+		if errors.Is(err, repos.ErrDuplicateIdentity) {
+			return nil, utils.GenerateError(ErrIdentityConflict, err)
+		}
+		return nil, utils.GenerateError(ErrLinkingIdentity, err)
+	}
+
+	user, err := oa.userRepo.FindUserByID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserIDNotFound, err)
+	}
+
+	return user, nil
+}
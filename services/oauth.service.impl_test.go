@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+)
+
+const testOIDCClientID = "gipitty"
+
+// A minimal OpenID Connect issuer: discovery, JWKS and a token endpoint that
+// checks PKCE and signs an ID token for whichever identity the test set up.
+type stubOIDCServer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	signer jose.Signer
+
+	mu       sync.Mutex
+	identity *models.ExternalIdentity
+	nonce    string
+	codes    map[string]string //Code to PKCE Challenge
+}
+
+func newStubOIDCServer(t *testing.T) *stubOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: key, KeyID: "test"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss := &stubOIDCServer{key: key, signer: signer, codes: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", ss.discovery)
+	mux.HandleFunc("/jwks", ss.jwks)
+	mux.HandleFunc("/token", ss.token)
+	ss.Server = httptest.NewServer(mux)
+	t.Cleanup(ss.Close)
+
+	return ss
+}
+
+// Plays the user approving the sign-in at the provider and returns the code.
+func (ss *stubOIDCServer) authorize(t *testing.T, authURL string, identity *models.ExternalIdentity) string {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("client_id") != testOIDCClientID || query.Get("code_challenge_method") != "S256" {
+		t.Fatalf("unexpected authorization request %s", authURL)
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	code := primitive.NewObjectID().Hex()
+	ss.codes[code] = query.Get("code_challenge")
+	ss.identity = identity
+	ss.nonce = query.Get("nonce")
+	return code
+}
+
+func (ss *stubOIDCServer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                ss.URL,
+		"authorization_endpoint":                ss.URL + "/authorize",
+		"token_endpoint":                        ss.URL + "/token",
+		"jwks_uri":                              ss.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (ss *stubOIDCServer) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &ss.key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"}}})
+}
+
+func (ss *stubOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	challenge, ok := ss.codes[r.PostFormValue("code")]
+	delete(ss.codes, r.PostFormValue("code"))
+	verifierHash := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(verifierHash[:]) != challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	idToken, err := jwt.Signed(ss.signer).Claims(jwt.Claims{
+		Issuer:   ss.URL,
+		Subject:  ss.identity.Subject,
+		Audience: jwt.Audience{testOIDCClientID},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Minute)),
+	}).Claims(map[string]interface{}{
+		"nonce":          ss.nonce,
+		"email":          ss.identity.Email,
+		"email_verified": ss.identity.EmailVerified,
+		"name":           ss.identity.Name,
+	}).CompactSerialize()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+// Keeps users in memory, matching the repo's not found and duplicate semantics.
+type fakeOAuthUserRepo struct {
+	repos.IUserRepo
+	users     []*models.DBResponse
+	lookupErr error
+}
+
+func (fr *fakeOAuthUserRepo) FindUserByIdentity(provider string, subject string) (*models.DBResponse, error) {
+	if fr.lookupErr != nil {
+		return nil, fr.lookupErr
+	}
+	for _, user := range fr.users {
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				return user, nil
+			}
+		}
+	}
+	return nil, repos.ErrUserNotFound
+}
+
+func (fr *fakeOAuthUserRepo) FindUserByEmail(email string) (*models.DBResponse, error) {
+	for _, user := range fr.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repos.ErrUserNotFound
+}
+
+func (fr *fakeOAuthUserRepo) FindUserByID(id string) (*models.DBResponse, error) {
+	for _, user := range fr.users {
+		if user.ID.Hex() == id {
+			return user, nil
+		}
+	}
+	return nil, repos.ErrUserNotFound
+}
+
+func (fr *fakeOAuthUserRepo) CreateNewUser(input *models.SignUpInput) (string, error) {
+	user := &models.DBResponse{ID: primitive.NewObjectID(), Name: input.Name, Email: input.Email, Password: input.Password, Role: input.Role, Status: input.Status, Verified: input.Verified}
+	fr.users = append(fr.users, user)
+	return user.ID.Hex(), nil
+}
+
+func (fr *fakeOAuthUserRepo) LinkIdentity(id string, identity *models.LinkedIdentity) error {
+	user, err := fr.FindUserByID(id)
+	if err != nil {
+		return err
+	}
+	for _, linked := range user.Identities {
+		if linked.Provider == identity.Provider {
+			return repos.ErrDuplicateIdentity
+		}
+	}
+	user.Identities = append(user.Identities, *identity)
+	return nil
+}
+
+func (fr *fakeOAuthUserRepo) UpdateUserById(id string, input *models.UpdateInput) error {
+	user, err := fr.FindUserByID(id)
+	if err != nil {
+		return err
+	}
+	user.Password, user.Verified = input.Password, input.Verified
+	return nil
+}
+
+func (fr *fakeOAuthUserRepo) UpdateUserRole(id string, role string) (*models.DBResponse, error) {
+	user, err := fr.FindUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
+
+type fakeOAuthStateRepo struct {
+	repos.ITokenRepo
+	states map[string][]byte
+}
+
+func (fr *fakeOAuthStateRepo) StoreOAuthState(state string, data []byte, ttl time.Duration) error {
+	fr.states[state] = data
+	return nil
+}
+
+func (fr *fakeOAuthStateRepo) ConsumeOAuthState(state string) ([]byte, error) {
+	data, ok := fr.states[state]
+	if !ok {
+		return nil, repos.ErrOAuthStateNotFound
+	}
+	delete(fr.states, state)
+	return data, nil
+}
+
+func newTestOAuthService(t *testing.T, userRepo *fakeOAuthUserRepo) (IOAuthService, *stubOIDCServer) {
+	server := newStubOIDCServer(t)
+	provider := NewOIDCProvider("stub", server.URL, oauth2.Config{
+		ClientID:     testOIDCClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8000/api/auth/oauth/stub/callback",
+	})
+
+	hasher, err := NewPasswordHasher(&config.Config{PasswordHashAlgorithm: "bcrypt", BcryptCost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewOAuthService(userRepo, &fakeOAuthStateRepo{states: map[string][]byte{}}, map[string]IOAuthProvider{"stub": provider}, hasher, context.Background())
+	return service, server
+}
+
+var testOAuthConfig = &config.Config{OAuthStateExpiresIn: time.Minute, AdminEmails: []string{"admin@example.com"}}
+
+func signInWithStub(t *testing.T, service IOAuthService, server *stubOIDCServer, identity *models.ExternalIdentity) (*models.DBResponse, error) {
+	authURL, state, err := service.BeginLogin("stub", testOAuthConfig)
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	return service.FinishLogin("stub", state, server.authorize(t, authURL, identity), testOAuthConfig)
+}
+
+func TestOAuthLoginCreatesAndLinksUser(t *testing.T) {
+	userRepo := &fakeOAuthUserRepo{}
+	service, server := newTestOAuthService(t, userRepo)
+	identity := &models.ExternalIdentity{Subject: "subject-1", Email: "Jane@Example.com", EmailVerified: true, Name: "Jane"}
+
+	user, err := signInWithStub(t, service, server, identity)
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if user.Email != "jane@example.com" || !user.Verified {
+		t.Fatalf("created user = %+v", user)
+	}
+	if len(user.Identities) != 1 || user.Identities[0].Provider != "stub" || user.Identities[0].Subject != "subject-1" {
+		t.Fatalf("linked identities = %+v", user.Identities)
+	}
+
+	//Signing In Again Finds the Linked User
+	again, err := signInWithStub(t, service, server, identity)
+	if err != nil {
+		t.Fatalf("second FinishLogin: %v", err)
+	}
+	if again.ID != user.ID || len(userRepo.users) != 1 {
+		t.Fatalf("second sign-in resolved %s among %d users, want %s", again.ID.Hex(), len(userRepo.users), user.ID.Hex())
+	}
+}
+
+func TestOAuthLoginRejectsUnverifiedEmail(t *testing.T) {
+	userRepo := &fakeOAuthUserRepo{}
+	service, server := newTestOAuthService(t, userRepo)
+
+	_, err := signInWithStub(t, service, server, &models.ExternalIdentity{Subject: "subject-1", Email: "jane@example.com"})
+	if !errors.Is(err, ErrOAuthEmailNotVerified) {
+		t.Fatalf("err = %v, want %v", err, ErrOAuthEmailNotVerified)
+	}
+	if len(userRepo.users) != 0 {
+		t.Fatal("user created from an unverified email")
+	}
+}
+
+func TestOAuthLoginRejectsReplayedState(t *testing.T) {
+	service, server := newTestOAuthService(t, &fakeOAuthUserRepo{})
+	identity := &models.ExternalIdentity{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true}
+
+	authURL, state, err := service.BeginLogin("stub", testOAuthConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.FinishLogin("stub", state, server.authorize(t, authURL, identity), testOAuthConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.FinishLogin("stub", state, server.authorize(t, authURL, identity), testOAuthConfig)
+	if !errors.Is(err, ErrOAuthStateInvalid) {
+		t.Fatalf("err = %v, want %v", err, ErrOAuthStateInvalid)
+	}
+}
+
+func TestOAuthLoginRejectsSecondIdentityFromProvider(t *testing.T) {
+	existing := &models.DBResponse{
+		ID:         primitive.NewObjectID(),
+		Email:      "jane@example.com",
+		Verified:   true,
+		Identities: []models.LinkedIdentity{{Provider: "stub", Subject: "subject-1"}},
+	}
+	service, server := newTestOAuthService(t, &fakeOAuthUserRepo{users: []*models.DBResponse{existing}})
+
+	//Another Provider Account Claiming the Same Email
+	_, err := signInWithStub(t, service, server, &models.ExternalIdentity{Subject: "subject-2", Email: "jane@example.com", EmailVerified: true})
+	if !errors.Is(err, ErrIdentityConflict) {
+		t.Fatalf("err = %v, want %v", err, ErrIdentityConflict)
+	}
+}
+
+func TestOAuthLoginDoesNotCreateUserOnLookupFailure(t *testing.T) {
+	userRepo := &fakeOAuthUserRepo{lookupErr: repos.ErrUserQuery}
+	service, server := newTestOAuthService(t, userRepo)
+
+	_, err := signInWithStub(t, service, server, &models.ExternalIdentity{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true})
+	if !errors.Is(err, ErrLinkingIdentity) {
+		t.Fatalf("err = %v, want %v", err, ErrLinkingIdentity)
+	}
+	if len(userRepo.users) != 0 {
+		t.Fatal("user created although the identity lookup failed")
+	}
+}
+
+func TestOAuthLoginPromotesBootstrapAdmin(t *testing.T) {
+	//Registered With a Password but Never Verified
+	unverified := &models.DBResponse{ID: primitive.NewObjectID(), Email: "admin@example.com", Role: models.RoleUser}
+	userRepo := &fakeOAuthUserRepo{users: []*models.DBResponse{unverified}}
+	service, server := newTestOAuthService(t, userRepo)
+
+	user, err := signInWithStub(t, service, server, &models.ExternalIdentity{Subject: "subject-1", Email: "admin@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if user.ID != unverified.ID || !user.Verified || user.Role != models.RoleAdmin {
+		t.Fatalf("linked user = %+v, want the existing account verified and promoted", user)
+	}
+
+	//Signing Up Through the Provider
+	userRepo = &fakeOAuthUserRepo{}
+	service, server = newTestOAuthService(t, userRepo)
+
+	user, err = signInWithStub(t, service, server, &models.ExternalIdentity{Subject: "subject-2", Email: "Admin@Example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if user.Role != models.RoleAdmin {
+		t.Fatalf("created user role = %q, want %q", user.Role, models.RoleAdmin)
+	}
+
+	//Other Addresses Are Left Alone
+	user, err = signInWithStub(t, service, server, &models.ExternalIdentity{Subject: "subject-3", Email: "jane@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if user.Role != models.RoleUser {
+		t.Fatalf("created user role = %q, want %q", user.Role, models.RoleUser)
+	}
+}
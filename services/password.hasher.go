@@ -0,0 +1,11 @@
+package services
+
+// Hashes passwords into a self-describing string, carrying the algorithm and
+// its parameters, so stored hashes survive changes to the configuration.
+type IPasswordHasher interface {
+	Hash(password string) (string, error)
+	//Verifies Against Any Supported Format, Not Only the Configured One
+	Verify(hashedPassword string, candidatePassword string) error
+	//Whether hashedPassword Uses Other Parameters Than New Hashes
+	NeedsRehash(hashedPassword string) bool
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+type PasswordHasher struct {
+	algorithm utils.PasswordHashAlgorithm
+}
+
+// Builds the hasher for the configured algorithm, failing on parameters that
+// would break or weaken every new hash so the problem surfaces at startup.
+func NewPasswordHasher(config *config.Config) (IPasswordHasher, error) {
+	var algorithm utils.PasswordHashAlgorithm
+	switch config.PasswordHashAlgorithm {
+	case "argon2id":
+		algorithm = utils.Argon2idHasher{
+			Memory:      config.Argon2Memory,
+			Iterations:  config.Argon2Iterations,
+			Parallelism: config.Argon2Parallelism,
+			SaltLength:  config.Argon2SaltLength,
+			KeyLength:   config.Argon2KeyLength,
+		}
+	case "bcrypt":
+		algorithm = utils.BcryptHasher{Cost: config.BcryptCost}
+	default:
+		return nil, utils.GenerateError(ErrLoadingConfig, fmt.Errorf("unsupported password hash algorithm %q", config.PasswordHashAlgorithm))
+	}
+
+	if err := algorithm.Validate(); err != nil {
+		return nil, utils.GenerateError(ErrLoadingConfig, err)
+	}
+
+	return &PasswordHasher{algorithm}, nil
+}
+
+func (ph PasswordHasher) Hash(password string) (string, error) {
+	hashedPassword, err := ph.algorithm.Hash(password)
+
+	if err != nil {
+		return "", fmt.Errorf("could not hash password %w", err)
+	}
+
+	return hashedPassword, nil
+}
+
+func (ph PasswordHasher) Verify(hashedPassword string, candidatePassword string) error {
+	return utils.VerifyPassword(hashedPassword, candidatePassword)
+}
+
+func (ph PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	return ph.algorithm.NeedsRehash(hashedPassword)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func argon2Config(modify func(*config.Config)) *config.Config {
+	config := &config.Config{
+		PasswordHashAlgorithm: "argon2id",
+		Argon2Memory:          64,
+		Argon2Iterations:      1,
+		Argon2Parallelism:     1,
+		Argon2SaltLength:      16,
+		Argon2KeyLength:       32,
+	}
+	if modify != nil {
+		modify(config)
+	}
+	return config
+}
+
+func TestNewPasswordHasherRejectsBadParameters(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *config.Config
+	}{
+		{"unknown algorithm", &config.Config{PasswordHashAlgorithm: "md5"}},
+		{"zero argon2 iterations", argon2Config(func(c *config.Config) { c.Argon2Iterations = 0 })},
+		{"zero argon2 parallelism", argon2Config(func(c *config.Config) { c.Argon2Parallelism = 0 })},
+		{"argon2 memory below parallelism minimum", argon2Config(func(c *config.Config) { c.Argon2Parallelism = 4; c.Argon2Memory = 16 })},
+		{"short argon2 salt", argon2Config(func(c *config.Config) { c.Argon2SaltLength = 4 })},
+		{"zero argon2 key length", argon2Config(func(c *config.Config) { c.Argon2KeyLength = 0 })},
+		{"bcrypt cost too low", &config.Config{PasswordHashAlgorithm: "bcrypt", BcryptCost: bcrypt.MinCost - 1}},
+		{"bcrypt cost too high", &config.Config{PasswordHashAlgorithm: "bcrypt", BcryptCost: bcrypt.MaxCost + 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPasswordHasher(tt.config); !errors.Is(err, ErrLoadingConfig) {
+				t.Fatalf("err = %v, want %v", err, ErrLoadingConfig)
+			}
+		})
+	}
+}
+
+func TestPasswordHasher(t *testing.T) {
+	hasher, err := NewPasswordHasher(argon2Config(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashedPassword, err := hasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hasher.Verify(hashedPassword, "correct horse battery staple"); err != nil {
+		t.Fatalf("Verify(correct) = %v", err)
+	}
+	if err := hasher.Verify(hashedPassword, "wrong"); err == nil {
+		t.Fatal("Verify(wrong) succeeded")
+	}
+	if hasher.NeedsRehash(hashedPassword) {
+		t.Fatal("fresh hash needs rehash")
+	}
+
+	//Hashes From Other Settings Still Verify, but Are Upgraded
+	bcryptHasher, err := NewPasswordHasher(&config.Config{PasswordHashAlgorithm: "bcrypt", BcryptCost: bcrypt.MinCost})
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacyHash, err := bcryptHasher.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := hasher.Verify(legacyHash, "correct horse battery staple"); err != nil {
+		t.Fatalf("Verify(bcrypt) = %v", err)
+	}
+	if !hasher.NeedsRehash(legacyHash) {
+		t.Fatal("bcrypt hash does not need rehash under argon2id")
+	}
+}
+
+func TestPasswordHasherRejectsMalformedArgon2Parameters(t *testing.T) {
+	hasher, err := NewPasswordHasher(argon2Config(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Would Panic Inside argon2 if Accepted
+	malformed := "$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	if err := hasher.Verify(malformed, "password"); err == nil {
+		t.Fatal("Verify accepted zero parallelism")
+	}
+}
@@ -0,0 +1,10 @@
+package services
+
+import "github.com/AmadoJunior/Gipitty/models"
+
+type IPasswordPolicy interface {
+	Check(password string, personalInfo ...string) []models.PasswordViolation
+}
+
+// A single policy rule, returning nil when the password passes it.
+type PasswordRule func(password string, personalInfo []string) *models.PasswordViolation
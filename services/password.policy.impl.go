@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+)
+
+// Bcrypt ignores everything past this many bytes.
+const maxPasswordBytes = 72
+
+// Carries the rules a password broke so they can be shown to the client.
+type PasswordPolicyError struct {
+	Violations []models.PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	codes := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		codes[i] = violation.Code
+	}
+	return "password violates policy: " + strings.Join(codes, ", ")
+}
+
+type PasswordPolicy struct {
+	rules []PasswordRule
+}
+
+func NewPasswordPolicy(rules ...PasswordRule) IPasswordPolicy {
+	return &PasswordPolicy{rules}
+}
+
+func (pp PasswordPolicy) Check(password string, personalInfo ...string) []models.PasswordViolation {
+	var violations []models.PasswordViolation
+	for _, rule := range pp.rules {
+		if violation := rule(password, personalInfo); violation != nil {
+			violations = append(violations, *violation)
+		}
+	}
+	return violations
+}
+
+// Builds the rules enabled in config. Fails when a breached password list is
+// configured but cannot be loaded.
+func PasswordRulesFromConfig(config *config.Config) ([]PasswordRule, error) {
+	maxBytes := config.PasswordMaxLength
+	if maxBytes < 1 || maxBytes > maxPasswordBytes {
+		maxBytes = maxPasswordBytes
+	}
+
+	rules := []PasswordRule{MinLengthRule(config.PasswordMinLength), MaxBytesRule(maxBytes)}
+	if config.PasswordRequireUppercase {
+		rules = append(rules, CharacterClassRule("uppercase", "an uppercase letter", unicode.IsUpper))
+	}
+	if config.PasswordRequireLowercase {
+		rules = append(rules, CharacterClassRule("lowercase", "a lowercase letter", unicode.IsLower))
+	}
+	if config.PasswordRequireDigit {
+		rules = append(rules, CharacterClassRule("digit", "a digit", unicode.IsDigit))
+	}
+	if config.PasswordRequireSymbol {
+		rules = append(rules, CharacterClassRule("symbol", "a symbol", isSymbol))
+	}
+	if config.PasswordDisallowPersonalInfo {
+		rules = append(rules, PersonalInfoRule())
+	}
+
+	if config.PasswordBreachedList != "" {
+		breached, err := LoadBreachedPasswords(config.PasswordBreachedList)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, BreachedPasswordRule(breached))
+	}
+
+	return rules, nil
+}
+
+func MinLengthRule(minLength int) PasswordRule {
+	return func(password string, _ []string) *models.PasswordViolation {
+		if len([]rune(password)) < minLength {
+			return &models.PasswordViolation{Code: "too_short", Message: "must be at least " + strconv.Itoa(minLength) + " characters"}
+		}
+		return nil
+	}
+}
+
+func MaxBytesRule(maxBytes int) PasswordRule {
+	return func(password string, _ []string) *models.PasswordViolation {
+		if len(password) > maxBytes {
+			return &models.PasswordViolation{Code: "too_long", Message: "must be at most " + strconv.Itoa(maxBytes) + " bytes"}
+		}
+		return nil
+	}
+}
+
+func CharacterClassRule(class string, description string, matches func(rune) bool) PasswordRule {
+	return func(password string, _ []string) *models.PasswordViolation {
+		if strings.IndexFunc(password, matches) < 0 {
+			return &models.PasswordViolation{Code: "missing_" + class, Message: "must contain " + description}
+		}
+		return nil
+	}
+}
+
+// Rejects passwords containing the email, its local part or a part of the
+// name. Parts shorter than three characters are ignored.
+func PersonalInfoRule() PasswordRule {
+	return func(password string, personalInfo []string) *models.PasswordViolation {
+		lowered := strings.ToLower(password)
+		for _, info := range personalInfo {
+			info = strings.ToLower(info)
+			parts := strings.FieldsFunc(info, func(r rune) bool {
+				return unicode.IsSpace(r) || r == '@'
+			})
+			for _, part := range append(parts, info) {
+				if len([]rune(part)) >= 3 && strings.Contains(lowered, part) {
+					return &models.PasswordViolation{Code: "personal_info", Message: "must not contain your name or email"}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Breached SHA-1 hashes grouped by their 5 character prefix.
+type BreachedPasswords map[string]map[string]struct{}
+
+func (bp BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := bp[hash[:5]][hash[5:]]
+	return found
+}
+
+// Loads a list of SHA-1 hashes, one per line, optionally followed by
+// ":<count>" as in the Have I Been Pwned downloads.
+func LoadBreachedPasswords(path string) (BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	breached := BreachedPasswords{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if len(hash) != sha1.Size*2 {
+			continue
+		}
+		hash = strings.ToUpper(hash)
+		if breached[hash[:5]] == nil {
+			breached[hash[:5]] = map[string]struct{}{}
+		}
+		breached[hash[:5]][hash[5:]] = struct{}{}
+	}
+
+	return breached, scanner.Err()
+}
+
+func BreachedPasswordRule(breached BreachedPasswords) PasswordRule {
+	return func(password string, _ []string) *models.PasswordViolation {
+		if breached.Contains(password) {
+			return &models.PasswordViolation{Code: "breached", Message: "has appeared in a data breach, please choose another"}
+		}
+		return nil
+	}
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+// Checks password against policy, returning a *PasswordPolicyError on failure.
+func checkPasswordPolicy(policy IPasswordPolicy, password string, personalInfo ...string) error {
+	if violations := policy.Check(password, personalInfo...); len(violations) > 0 {
+		return &PasswordPolicyError{violations}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package services
+
+import "github.com/AmadoJunior/Gipitty/models"
+
+type ISessionService interface {
+	FindSessionsByUserId(userID string) ([]*models.Session, error)
+	RevokeSession(id string, userID string) error
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+type SessionService struct {
+	sessionRepo repos.ISessionRepo
+	tokenRepo   repos.ITokenRepo
+	ctx         context.Context
+}
+
+func NewSessionService(sessionRepo repos.ISessionRepo, tokenRepo repos.ITokenRepo, ctx context.Context) ISessionService {
+	return &SessionService{sessionRepo, tokenRepo, ctx}
+}
+
+func (ss SessionService) FindSessionsByUserId(userID string) ([]*models.Session, error) {
+	sessions, err := ss.sessionRepo.FindSessionsByUserID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrFindingSessions, err)
+	}
+	return sessions, nil
+}
+
+func (ss SessionService) RevokeSession(id string, userID string) error {
+	session, err := ss.sessionRepo.DeleteSessionByID(id, userID)
+	if err != nil {
+		//Not Found or Not Owned by User
+		return utils.GenerateError(ErrSessionNotFound, err)
+	}
+
+	err = ss.tokenRepo.RevokeRefreshFamily(session.Family)
+	if err != nil {
+		//Failed to Revoke Refresh Tokens
+		return utils.GenerateError(ErrRevokingToken, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,8 @@
+package services
+
+type ISigningKeyService interface {
+	Seed() error
+	Sync() error
+	Rotate() error
+	Start()
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+type keyRingSpec struct {
+	name      string
+	ring      *config.KeyRing
+	retention time.Duration
+}
+
+// Shares the token key rings between instances through the signing key
+// store, with private keys encrypted. New keys are published the configured
+// lead time before they activate so every instance, and every verifier with
+// a cached JWKS, can verify them before any instance signs with them.
+type SigningKeyService struct {
+	signingKeyRepo repos.ISigningKeyRepo
+	config         *config.Config
+	ctx            context.Context
+}
+
+func NewSigningKeyService(signingKeyRepo repos.ISigningKeyRepo, config *config.Config, ctx context.Context) ISigningKeyService {
+	return &SigningKeyService{signingKeyRepo, config, ctx}
+}
+
+func (ss SigningKeyService) rings() []keyRingSpec {
+	return []keyRingSpec{
+		{"access", ss.config.AccessTokenKeys, ss.config.AccessTokenExpiresIn},
+		{"refresh", ss.config.RefreshTokenKeys, ss.config.RefreshTokenExpiresIn},
+	}
+}
+
+// Publishes the environment keys. With rotation enabled they only seed an
+// empty store, otherwise they are authoritative and replacing one rotates.
+// Keys stored before encryption are encrypted in place.
+func (ss SigningKeyService) Seed() error {
+	for _, spec := range ss.rings() {
+		stored, err := ss.signingKeyRepo.FindSigningKeys(spec.name)
+		if err != nil {
+			return utils.GenerateError(ErrSyncingSigningKeys, err)
+		}
+		if err := ss.encryptLegacyKeys(spec, stored); err != nil {
+			return err
+		}
+		if len(stored) > 0 && ss.config.TokenKeyRotationInterval > 0 {
+			continue
+		}
+
+		key, err := spec.ring.SigningKey()
+		if err != nil {
+			//No Environment Key, Start the Ring With a Generated One
+			key, err = config.GenerateSigningKey(spec.ring.Algorithm())
+			if err != nil {
+				return utils.GenerateError(ErrRotatingSigningKey, err)
+			}
+		}
+
+		if err := ss.publish(spec, key); err != nil {
+			return err
+		}
+	}
+
+	return ss.Sync()
+}
+
+func (ss SigningKeyService) Sync() error {
+	for _, spec := range ss.rings() {
+		stored, err := ss.signingKeyRepo.FindSigningKeys(spec.name)
+		if err != nil {
+			return utils.GenerateError(ErrSyncingSigningKeys, err)
+		}
+
+		keys := make([]*config.SigningKey, 0, len(stored))
+		for _, doc := range stored {
+			key, err := ss.openSigningKey(doc)
+			if err != nil {
+				return utils.GenerateError(ErrSyncingSigningKeys, err)
+			}
+			key.ActivatesAt = doc.ActivatesAt
+			key.ExpiresAt = doc.ExpiresAt
+			keys = append(keys, key)
+		}
+
+		spec.ring.Replace(keys)
+	}
+
+	return nil
+}
+
+// Publishes a fresh key for every ring whose newest key is older than the
+// rotation interval and has no successor pending, or whose newest key uses
+// an algorithm other than the configured one.
+func (ss SigningKeyService) Rotate() error {
+	if ss.config.TokenKeyRotationInterval <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, spec := range ss.rings() {
+		keys := spec.ring.Keys()
+		if len(keys) > 0 && keys[0].Algorithm == spec.ring.Algorithm() && now.Sub(keys[0].ActivatesAt) < ss.config.TokenKeyRotationInterval {
+			continue
+		}
+
+		key, err := config.GenerateSigningKey(spec.ring.Algorithm())
+		if err != nil {
+			return utils.GenerateError(ErrRotatingSigningKey, err)
+		}
+
+		if err := ss.publish(spec, key); err != nil {
+			return err
+		}
+	}
+
+	return ss.Sync()
+}
+
+// Periodically picks up keys published by other instances and rotates when due.
+func (ss SigningKeyService) Start() {
+	if ss.config.TokenKeySyncInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(ss.config.TokenKeySyncInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ss.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := ss.Sync(); err != nil {
+					log.Println("signing key sync:", err)
+				}
+				if err := ss.Rotate(); err != nil {
+					log.Println("signing key rotation:", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stores a key to activate after the publish lead time, and schedules the ring's
+// other keys to expire once every token they signed has expired.
+func (ss SigningKeyService) publish(spec keyRingSpec, key *config.SigningKey) error {
+	privateKey, err := config.SealSigningKey(key, ss.config.TokenKeyEncryptionKey)
+	if err != nil {
+		return utils.GenerateError(ErrRotatingSigningKey, err)
+	}
+
+	now := time.Now()
+	activatesAt := now.Add(ss.config.TokenKeyPublishLead)
+	err = ss.signingKeyRepo.CreateSigningKey(&models.SigningKey{
+		KeyID:       key.ID,
+		Ring:        spec.name,
+		Algorithm:   key.Algorithm,
+		PrivateKey:  privateKey,
+		Encrypted:   true,
+		CreatedAt:   now,
+		ActivatesAt: activatesAt,
+	})
+	if errors.Is(err, repos.ErrDuplicateSigningKey) {
+		//Already Published
+		return nil
+	}
+	if err != nil {
+		return utils.GenerateError(ErrRotatingSigningKey, err)
+	}
+
+	err = ss.signingKeyRepo.ExpireSigningKeys(spec.name, key.ID, activatesAt.Add(spec.retention+ss.config.TokenKeySyncInterval))
+	if err != nil {
+		return utils.GenerateError(ErrRotatingSigningKey, err)
+	}
+
+	return nil
+}
+
+func (ss SigningKeyService) openSigningKey(doc *models.SigningKey) (*config.SigningKey, error) {
+	if !doc.Encrypted {
+		return config.ParseSigningKey(doc.PrivateKey)
+	}
+	return config.OpenSigningKey(doc.PrivateKey, ss.config.TokenKeyEncryptionKey)
+}
+
+func (ss SigningKeyService) encryptLegacyKeys(spec keyRingSpec, stored []*models.SigningKey) error {
+	for _, doc := range stored {
+		if doc.Encrypted {
+			continue
+		}
+
+		key, err := config.ParseSigningKey(doc.PrivateKey)
+		if err != nil {
+			return utils.GenerateError(ErrSyncingSigningKeys, err)
+		}
+		sealed, err := config.SealSigningKey(key, ss.config.TokenKeyEncryptionKey)
+		if err != nil {
+			return utils.GenerateError(ErrSyncingSigningKeys, err)
+		}
+		if err := ss.signingKeyRepo.EncryptSigningKey(spec.name, doc.KeyID, sealed); err != nil {
+			return utils.GenerateError(ErrSyncingSigningKeys, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+)
+
+const (
+	oneTimeTokenMigration = "one-time-token-digests"
+	recoveryCodeMigration = "recovery-code-digests"
+)
+
+// Replaces one-time tokens stored base64 encoded, as they were before digests
+// were used, with their digests so outstanding links keep working, and keys
+// recovery code digests stored before they used the pepper. Each runs once,
+// recorded in the migration repo; the digests depend on TOKEN_PEPPER at that
+// time, see UseTokenPepper.
+func MigrateOneTimeTokens(migrationRepo repos.IMigrationRepo, userRepo repos.IUserRepo, exportRepo repos.IExportRepo, config *config.Config) error {
+	err := runMigration(migrationRepo, oneTimeTokenMigration, func() (int64, error) {
+		userTokens, err := userRepo.MigrateTokenDigests(digestEncodedToken, time.Now().Add(config.VerificationCodeExpiresIn))
+		if err != nil {
+			return userTokens, err
+		}
+		exportTokens, err := exportRepo.MigrateTokenDigests(digestEncodedToken)
+		return userTokens + exportTokens, err
+	})
+	if err != nil {
+		return err
+	}
+
+	//Stored Codes Are the Inner Digest of HashRecoveryCode
+	return runMigration(migrationRepo, recoveryCodeMigration, func() (int64, error) {
+		return userRepo.MigrateRecoveryCodeDigests(utils.HashOneTimeToken)
+	})
+}
+
+func runMigration(migrationRepo repos.IMigrationRepo, name string, migrate func() (int64, error)) error {
+	applied, err := migrationRepo.IsApplied(name)
+	if err != nil || applied {
+		return err
+	}
+
+	migrated, err := migrate()
+	if err != nil {
+		return err
+	}
+	if migrated > 0 {
+		log.Println("Migrated", migrated, "documents for", name)
+	}
+	return migrationRepo.MarkApplied(name)
+}
+
+func digestEncodedToken(encoded string) (string, error) {
+	token, err := utils.Decode(encoded)
+	if err != nil {
+		return "", err
+	}
+	return utils.HashOneTimeToken(token), nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeMigrationRepo struct {
+	repos.IMigrationRepo
+	applied map[string]bool
+}
+
+func (fr *fakeMigrationRepo) IsApplied(name string) (bool, error) {
+	return fr.applied[name], nil
+}
+
+func (fr *fakeMigrationRepo) MarkApplied(name string) error {
+	fr.applied[name] = true
+	return nil
+}
+
+type fakeMigrationUserRepo struct {
+	fakeMFAUserRepo
+}
+
+func (fr *fakeMigrationUserRepo) MigrateTokenDigests(convert func(string) (string, error), verificationCodeExpiresAt time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (fr *fakeMigrationUserRepo) MigrateRecoveryCodeDigests(convert func(string) string) (int64, error) {
+	for i, code := range fr.user.RecoveryCodes {
+		fr.user.RecoveryCodes[i] = convert(code)
+	}
+	return int64(len(fr.user.RecoveryCodes)), nil
+}
+
+type fakeMigrationExportRepo struct {
+	repos.IExportRepo
+}
+
+func (fr *fakeMigrationExportRepo) MigrateTokenDigests(convert func(string) (string, error)) (int64, error) {
+	return 0, nil
+}
+
+func TestMigrateOneTimeTokensKeysRecoveryCodes(t *testing.T) {
+	utils.UseTokenPepper("pepper")
+	t.Cleanup(func() { utils.UseTokenPepper("") })
+
+	//Stored the Way Codes Were Before the Pepper Applied to Them
+	legacy := sha256.Sum256([]byte("abcdeefghj"))
+	user := &models.DBResponse{ID: primitive.NewObjectID(), TOTPEnabled: true, RecoveryCodes: []string{hex.EncodeToString(legacy[:])}}
+	userRepo := &fakeMigrationUserRepo{fakeMFAUserRepo{user: user}}
+	migrationRepo := &fakeMigrationRepo{applied: map[string]bool{oneTimeTokenMigration: true}}
+
+	for i := 0; i < 2; i++ {
+		if err := MigrateOneTimeTokens(migrationRepo, userRepo, &fakeMigrationExportRepo{}, &config.Config{}); err != nil {
+			t.Fatalf("MigrateOneTimeTokens: %v", err)
+		}
+	}
+	if !migrationRepo.applied[recoveryCodeMigration] {
+		t.Fatal("recovery code migration not recorded")
+	}
+
+	if err := verifySecondFactor(userRepo, user, "ABCDE-EFGHJ"); err != nil {
+		t.Fatalf("migrated recovery code rejected: %v", err)
+	}
+}
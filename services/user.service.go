@@ -9,8 +9,18 @@ type IUserService interface {
 	FindUserById(id string) (*models.DBResponse, error)
 	FindUserByEmail(email string) (*models.DBResponse, error)
 	UpdateUserById(id string, data *models.UpdateInput) (*models.DBResponse, error)
+	UpdateProfile(id string, profile *models.UpdateProfileInput) (*models.DBResponse, error)
+	ChangePassword(user *models.DBResponse, input *models.ChangePasswordInput, config *config.Config) error
+	RequestEmailChange(user *models.DBResponse, input *models.ChangeEmailInput, config *config.Config) error
+	ConfirmEmailChange(emailChangeToken string) error
 	SendVerificationEmail(newUser *models.DBResponse) error
-	VerifyUserEmail(verificationCode string) error
+	VerifyUserEmail(verificationCode string, config *config.Config) error
 	InitResetPassword(*models.DBResponse, *config.Config) error
 	ResetUserPassword(passwordResetToken string, newPassword string) error
+	FindUsers(query *models.UserQuery) ([]*models.DBResponse, int64, error)
+	UpdateUserRole(id string, role string) (*models.DBResponse, error)
+	MarkUserVerified(id string, config *config.Config) (*models.DBResponse, error)
+	SetUserStatus(id string, status string, reason string) (*models.DBResponse, error)
+	ForcePasswordReset(id string, config *config.Config) error
+	BootstrapAdmins(emails []string) error
 }
@@ -3,7 +3,9 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/AmadoJunior/Gipitty/config"
 	"github.com/AmadoJunior/Gipitty/models"
@@ -12,13 +14,17 @@ import (
 	"github.com/thanhpk/randstr"
 )
 
+const defaultUserPageLimit = 20
+
 type UserService struct {
 	userRepo repos.IUserRepo
+	policy   IPasswordPolicy
+	hasher   IPasswordHasher
 	ctx      context.Context
 }
 
-func NewUserService(userRepo repos.IUserRepo, ctx context.Context) IUserService {
-	return &UserService{userRepo, ctx}
+func NewUserService(userRepo repos.IUserRepo, policy IPasswordPolicy, hasher IPasswordHasher, ctx context.Context) IUserService {
+	return &UserService{userRepo, policy, hasher, ctx}
 }
 
 func (us UserService) FindUserById(id string) (*models.DBResponse, error) {
@@ -45,6 +51,124 @@ func (us UserService) UpdateUserById(id string, data *models.UpdateInput) (*mode
 	return user, nil
 }
 
+func (us UserService) UpdateProfile(id string, profile *models.UpdateProfileInput) (*models.DBResponse, error) {
+	if profile.Name != nil {
+		name := strings.TrimSpace(*profile.Name)
+		if name == "" {
+			return nil, utils.GenerateError(ErrInvalidProfileInput, errors.New("name is blank"))
+		}
+		profile.Name = &name
+	}
+
+	user, err := us.userRepo.UpdateUserProfile(id, profile)
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserIDNotFound, err)
+	}
+	return user, nil
+}
+
+// Replaces the password of a signed-in user. Revoking their other sessions is
+// left to the caller, which also has to re-issue tokens for the current one.
+func (us UserService) ChangePassword(user *models.DBResponse, input *models.ChangePasswordInput, config *config.Config) error {
+	if err := us.hasher.Verify(user.Password, input.CurrentPassword); err != nil {
+		return utils.GenerateError(ErrIncorrectPassword, err)
+	}
+
+	if err := us.hasher.Verify(user.Password, input.Password); err == nil {
+		return utils.GenerateError(ErrPasswordUnchanged, errors.New(user.ID.Hex()))
+	}
+
+	if err := checkPasswordPolicy(us.policy, input.Password, user.Email, user.Name); err != nil {
+		return utils.GenerateError(ErrWeakPassword, err)
+	}
+
+	hashedPassword, err := us.hasher.Hash(input.Password)
+	if err != nil {
+		return utils.GenerateError(ErrHashingPassword, err)
+	}
+
+	if err := us.userRepo.UpdateUserPassword(user.ID.Hex(), hashedPassword); err != nil {
+		return utils.GenerateError(ErrUpdatingPassword, err)
+	}
+
+	// Send Notification
+	emailData := utils.EmailData{
+		URL:       config.Origin + "/forgotpassword",
+		FirstName: getFirstName(user.Name),
+		Subject:   "Your password was changed",
+	}
+
+	err = utils.SendEmail(user, &emailData, "passwordChanged.html")
+	if err != nil {
+		//Changed, but Owner Not Notified
+		return utils.GenerateError(ErrSendingEmail, err)
+	}
+
+	return nil
+}
+
+// Emails a confirmation link to the new address and a notice to the current
+// one. The email only changes once the link is followed.
+func (us UserService) RequestEmailChange(user *models.DBResponse, input *models.ChangeEmailInput, config *config.Config) error {
+	if err := us.hasher.Verify(user.Password, input.Password); err != nil {
+		return utils.GenerateError(ErrIncorrectPassword, err)
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(input.Email))
+	if newEmail == user.Email {
+		return utils.GenerateError(ErrEmailUnchanged, errors.New(newEmail))
+	}
+	if _, err := us.userRepo.FindUserByEmail(newEmail); err == nil {
+		return utils.GenerateError(ErrEmailInUse, errors.New(newEmail))
+	}
+
+	token, digest := utils.NewOneTimeToken(32)
+	err := us.userRepo.StoreEmailChange(user.ID.Hex(), newEmail, digest, time.Now().Add(config.EmailChangeExpiresIn))
+	if err != nil {
+		return utils.GenerateError(ErrStoringToken, err)
+	}
+
+	// Send Confirmation to the New Address
+	recipient := *user
+	recipient.Email = newEmail
+	emailData := utils.EmailData{
+		URL:       config.Origin + "/confirmemail/" + token,
+		FirstName: getFirstName(user.Name),
+		Subject:   "Confirm your new email address",
+	}
+
+	err = utils.SendEmail(&recipient, &emailData, "confirmEmailChange.html")
+	if err != nil {
+		return utils.GenerateError(ErrSendingEmail, err)
+	}
+
+	// Send Notice to the Current Address
+	emailData = utils.EmailData{
+		URL:       config.Origin + "/forgotpassword",
+		FirstName: getFirstName(user.Name),
+		Subject:   "A change of your email address was requested",
+	}
+
+	err = utils.SendEmail(user, &emailData, "emailChangeNotice.html")
+	if err != nil {
+		return utils.GenerateError(ErrSendingEmail, err)
+	}
+
+	return nil
+}
+
+func (us UserService) ConfirmEmailChange(emailChangeToken string) error {
+	_, err := us.userRepo.ConfirmEmailChange(utils.HashOneTimeToken(emailChangeToken))
+	if err != nil {
+		//Address Taken Since the Change Was Requested
+		if errors.Is(err, repos.ErrDuplicateEmail) {
+			return utils.GenerateError(ErrEmailInUse, err)
+		}
+		return utils.GenerateError(ErrEmailChangeNotFound, err)
+	}
+	return nil
+}
+
 func (us UserService) SendVerificationEmail(newUser *models.DBResponse) error {
 	config, err := config.LoadConfig(".")
 	if err != nil {
@@ -53,36 +177,22 @@ func (us UserService) SendVerificationEmail(newUser *models.DBResponse) error {
 	}
 
 	// Generate Verification Code
-	code := randstr.String(20)
-	verificationCode := utils.Encode(code)
+	code, verificationCode := utils.NewOneTimeToken(20)
+	expiresAt := time.Now().Add(config.VerificationCodeExpiresIn)
 
 	//Update User Async
-	errorChan := make(chan error)
+	errorChan := make(chan error, 1)
 
 	go func() {
 		defer close(errorChan)
-		// Update User in Database
-		updateData := &models.UpdateInput{
-			VerificationCode: verificationCode,
-		}
-		_, err = us.UpdateUserById(newUser.ID.Hex(), updateData)
-		if err != nil {
-			errorChan <- err
-		}
-		errorChan <- nil
+		// Update User in Database, Replacing Any Earlier Code
+		errorChan <- us.userRepo.StoreVerificationCode(newUser.ID.Hex(), verificationCode, expiresAt)
 	}()
 
-	//Get firstName
-	var firstName = newUser.Name
-
-	if strings.Contains(firstName, " ") {
-		firstName = strings.Split(firstName, " ")[1]
-	}
-
 	// Send Email
 	emailData := utils.EmailData{
 		URL:       config.Origin + "/verifyemail/" + code,
-		FirstName: firstName,
+		FirstName: getFirstName(newUser.Name),
 		Subject:   "Your account verification code",
 	}
 
@@ -101,35 +211,37 @@ func (us UserService) SendVerificationEmail(newUser *models.DBResponse) error {
 	return nil
 }
 
-func (us UserService) VerifyUserEmail(code string) error {
-	verificationCode := utils.Encode(code)
-	return us.userRepo.VerifyUserEmail(verificationCode)
+func (us UserService) VerifyUserEmail(code string, config *config.Config) error {
+	verificationCode := utils.HashOneTimeToken(code)
+	user, err := us.userRepo.VerifyUserEmail(verificationCode)
+	if err != nil {
+		return err
+	}
+
+	//Configured Admins Signing Up After Startup
+	if _, err := promoteBootstrapAdmin(us.userRepo, user, config.AdminEmails); err != nil {
+		log.Println("Failed to promote admin:", err)
+	}
+
+	return nil
 }
 
 func (us UserService) InitResetPassword(user *models.DBResponse, config *config.Config) error {
 	// Generate Verification Code
-	resetToken := randstr.String(20)
+	resetToken, passwordResetToken := utils.NewOneTimeToken(20)
 
-	passwordResetToken := utils.Encode(resetToken)
-
-	err := us.userRepo.StorePasswordResetToken(user.Email, passwordResetToken)
+	err := us.userRepo.StorePasswordResetToken(user.Email, passwordResetToken, time.Now().Add(config.PasswordResetExpiresIn))
 
 	if err != nil {
 		//Error Storing Reset Token
 		return utils.GenerateError(ErrUserEmailNotFound, err)
 	}
 
-	var firstName = user.Name
-
-	if strings.Contains(firstName, " ") {
-		firstName = strings.Split(firstName, " ")[1]
-	}
-
 	// Send Email
 	emailData := utils.EmailData{
 		URL:       config.Origin + "/resetpassword/" + resetToken,
-		FirstName: firstName,
-		Subject:   "Your password reset token (valid for 10min)",
+		FirstName: getFirstName(user.Name),
+		Subject:   "Your password reset token (valid for " + utils.HumanizeDuration(config.PasswordResetExpiresIn) + ")",
 	}
 
 	err = utils.SendEmail(user, &emailData, "resetPassword.html")
@@ -142,12 +254,21 @@ func (us UserService) InitResetPassword(user *models.DBResponse, config *config.
 }
 
 func (us UserService) ResetUserPassword(resetToken string, newPassword string) error {
+	user, err := us.userRepo.FindUserByResetToken(utils.HashOneTimeToken(resetToken))
+	if err != nil {
+		return utils.GenerateError(ErrResetTokenNotFound, err)
+	}
+
+	if err := checkPasswordPolicy(us.policy, newPassword, user.Email, user.Name); err != nil {
+		return utils.GenerateError(ErrWeakPassword, err)
+	}
+
 	errChan := make(chan error)
 	outChan := make(chan string)
 	go func() {
 		defer close(errChan)
 		defer close(outChan)
-		result, err := utils.HashPassword(newPassword)
+		result, err := us.hasher.Hash(newPassword)
 		if err != nil {
 			errChan <- err
 		} else {
@@ -155,7 +276,7 @@ func (us UserService) ResetUserPassword(resetToken string, newPassword string) e
 		}
 	}()
 
-	passwordResetToken := utils.Encode(resetToken)
+	passwordResetToken := utils.HashOneTimeToken(resetToken)
 	var hashedPassword string
 	select {
 	case err := <-errChan:
@@ -165,7 +286,7 @@ func (us UserService) ResetUserPassword(resetToken string, newPassword string) e
 	case hashedPassword = <-outChan:
 	}
 
-	err := us.userRepo.ResetUserPassword(passwordResetToken, hashedPassword)
+	err = us.userRepo.ResetUserPassword(passwordResetToken, hashedPassword)
 
 	if err != nil {
 		if errors.Is(err, repos.ErrUserNotFound) {
@@ -178,3 +299,129 @@ func (us UserService) ResetUserPassword(resetToken string, newPassword string) e
 
 	return nil
 }
+
+func (us UserService) FindUsers(query *models.UserQuery) ([]*models.DBResponse, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 {
+		query.Limit = defaultUserPageLimit
+	}
+
+	users, total, err := us.userRepo.FindUsers(query)
+	if err != nil {
+		return nil, 0, utils.GenerateError(ErrFindingUsers, err)
+	}
+	return users, total, nil
+}
+
+func (us UserService) MarkUserVerified(id string, config *config.Config) (*models.DBResponse, error) {
+	user, err := us.userRepo.MarkUserVerified(id)
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserIDNotFound, err)
+	}
+
+	promoted, err := promoteBootstrapAdmin(us.userRepo, user, config.AdminEmails)
+	if err != nil {
+		log.Println("Failed to promote admin:", err)
+		return user, nil
+	}
+
+	return promoted, nil
+}
+
+func (us UserService) SetUserStatus(id string, status string, reason string) (*models.DBResponse, error) {
+	user, err := us.userRepo.SetUserStatus(id, status, reason)
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserIDNotFound, err)
+	}
+	return user, nil
+}
+
+// Replaces the password with an unusable random one and emails the owner a
+// reset link, so the old password stops working immediately.
+func (us UserService) ForcePasswordReset(id string, config *config.Config) error {
+	user, err := us.userRepo.FindUserByID(id)
+	if err != nil {
+		return utils.GenerateError(ErrUserIDNotFound, err)
+	}
+
+	hashedPassword, err := us.hasher.Hash(randstr.String(32))
+	if err != nil {
+		return utils.GenerateError(ErrHashingPassword, err)
+	}
+
+	err = us.userRepo.UpdateUserPassword(id, hashedPassword)
+	if err != nil {
+		return utils.GenerateError(ErrUpdatingPassword, err)
+	}
+
+	return us.InitResetPassword(user, config)
+}
+
+func (us UserService) UpdateUserRole(id string, role string) (*models.DBResponse, error) {
+	if !models.IsRole(role) {
+		return nil, utils.GenerateError(ErrInvalidRole, errors.New(role))
+	}
+
+	user, err := us.userRepo.UpdateUserRole(id, role)
+	if err != nil {
+		return nil, utils.GenerateError(ErrUserIDNotFound, err)
+	}
+	return user, nil
+}
+
+// Promotes the configured accounts to admin so a fresh deployment has
+// someone able to grant roles. Only verified accounts are promoted, so an
+// address cannot be claimed by signing up with it first. Accounts verified
+// later are promoted as part of verification.
+func (us UserService) BootstrapAdmins(emails []string) error {
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		user, err := us.userRepo.FindUserByEmail(email)
+		if err != nil {
+			continue
+		}
+
+		if _, err := promoteBootstrapAdmin(us.userRepo, user, emails); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the user promoted to admin if their verified email is configured,
+// otherwise the user unchanged. Called wherever an account becomes verified.
+func promoteBootstrapAdmin(userRepo repos.IUserRepo, user *models.DBResponse, emails []string) (*models.DBResponse, error) {
+	if !user.Verified || user.Role == models.RoleAdmin {
+		return user, nil
+	}
+
+	for _, email := range emails {
+		if !strings.EqualFold(strings.TrimSpace(email), user.Email) {
+			continue
+		}
+
+		promoted, err := userRepo.UpdateUserRole(user.ID.Hex(), models.RoleAdmin)
+		if err != nil {
+			return nil, utils.GenerateError(ErrBootstrappingAdmin, err)
+		}
+		return promoted, nil
+	}
+
+	return user, nil
+}
+
+func getFirstName(name string) string {
+	var firstName = name
+
+	if strings.Contains(firstName, " ") {
+		firstName = strings.Split(firstName, " ")[1]
+	}
+
+	return firstName
+}
@@ -0,0 +1,17 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+type IWebAuthnService interface {
+	BeginRegistration(user *models.DBResponse) (*protocol.CredentialCreation, error)
+	FinishRegistration(user *models.DBResponse, name string, request *http.Request) (*models.WebAuthnCredential, error)
+	BeginLogin() (*protocol.CredentialAssertion, string, error)
+	FinishLogin(sessionID string, request *http.Request) (*models.DBResponse, error)
+	FindCredentialsByUserId(userID string) ([]*models.WebAuthnCredential, error)
+	DeleteCredential(id string, userID string) error
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/AmadoJunior/Gipitty/utils"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/thanhpk/randstr"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const webAuthnSessionTTL = 5 * time.Minute
+
+// Adapts a user and their stored passkeys to the webauthn.User interface.
+type webAuthnUser struct {
+	user        *models.DBResponse
+	credentials []webauthn.Credential
+}
+
+func (wu webAuthnUser) WebAuthnID() []byte {
+	return wu.user.ID[:]
+}
+
+func (wu webAuthnUser) WebAuthnName() string {
+	return wu.user.Email
+}
+
+func (wu webAuthnUser) WebAuthnDisplayName() string {
+	return wu.user.Name
+}
+
+func (wu webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (wu webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return wu.credentials
+}
+
+type WebAuthnService struct {
+	userRepo       repos.IUserRepo
+	credentialRepo repos.ICredentialRepo
+	tokenRepo      repos.ITokenRepo
+	webAuthn       *webauthn.WebAuthn
+	ctx            context.Context
+}
+
+func NewWebAuthnService(userRepo repos.IUserRepo, credentialRepo repos.ICredentialRepo, tokenRepo repos.ITokenRepo, config *config.Config, ctx context.Context) (IWebAuthnService, error) {
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          config.WebAuthnRPID,
+		RPDisplayName: config.WebAuthnRPDisplayName,
+		RPOrigins:     config.WebAuthnRPOrigins,
+	})
+	if err != nil {
+		return nil, utils.GenerateError(ErrLoadingConfig, err)
+	}
+
+	return &WebAuthnService{userRepo, credentialRepo, tokenRepo, webAuthn, ctx}, nil
+}
+
+func (ws WebAuthnService) BeginRegistration(user *models.DBResponse) (*protocol.CredentialCreation, error) {
+	waUser, err := ws.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	//Exclude Already Registered Authenticators
+	exclusions := make([]protocol.CredentialDescriptor, len(waUser.credentials))
+	for i, credential := range waUser.credentials {
+		exclusions[i] = credential.Descriptor()
+	}
+
+	creation, session, err := ws.webAuthn.BeginRegistration(waUser,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		return nil, utils.GenerateError(ErrWebAuthnCeremony, err)
+	}
+
+	if err := ws.storeSession("registration:"+user.ID.Hex(), session); err != nil {
+		return nil, err
+	}
+
+	return creation, nil
+}
+
+func (ws WebAuthnService) FinishRegistration(user *models.DBResponse, name string, request *http.Request) (*models.WebAuthnCredential, error) {
+	session, err := ws.consumeSession("registration:" + user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	waUser, err := ws.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := ws.webAuthn.FinishRegistration(waUser, *session, request)
+	if err != nil {
+		return nil, utils.GenerateError(ErrWebAuthnCeremony, err)
+	}
+
+	if name == "" {
+		name = "Passkey"
+	}
+
+	stored := &models.WebAuthnCredential{
+		UserID:       user.ID,
+		Name:         name,
+		CredentialID: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Credential:   *credential,
+		CreatedAt:    time.Now(),
+	}
+
+	id, err := ws.credentialRepo.CreateCredential(stored)
+	if err != nil {
+		if errors.Is(err, repos.ErrDuplicateCredential) {
+			return nil, utils.GenerateError(ErrWebAuthnCeremony, err)
+		}
+		return nil, utils.GenerateError(ErrStoringCredential, err)
+	}
+	stored.ID, _ = primitive.ObjectIDFromHex(id)
+
+	return stored, nil
+}
+
+// Starts a discoverable (username-less) login. The returned session id must
+// be presented again when finishing the ceremony.
+func (ws WebAuthnService) BeginLogin() (*protocol.CredentialAssertion, string, error) {
+	assertion, session, err := ws.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, "", utils.GenerateError(ErrWebAuthnCeremony, err)
+	}
+
+	sessionID := randstr.Hex(32)
+	if err := ws.storeSession("login:"+sessionID, session); err != nil {
+		return nil, "", err
+	}
+
+	return assertion, sessionID, nil
+}
+
+func (ws WebAuthnService) FinishLogin(sessionID string, request *http.Request) (*models.DBResponse, error) {
+	session, err := ws.consumeSession("login:" + sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponse(request)
+	if err != nil {
+		return nil, utils.GenerateError(ErrWebAuthnCeremony, err)
+	}
+
+	var waUser *webAuthnUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		if len(userHandle) != len(primitive.ObjectID{}) {
+			return nil, errors.New("malformed user handle")
+		}
+
+		var userID primitive.ObjectID
+		copy(userID[:], userHandle)
+		user, err := ws.userRepo.FindUserByID(userID.Hex())
+		if err != nil {
+			return nil, err
+		}
+
+		waUser, err = ws.loadUser(user)
+		return waUser, err
+	}
+
+	credential, err := ws.webAuthn.ValidateDiscoverableLogin(handler, *session, parsed)
+	if err != nil {
+		return nil, utils.GenerateError(ErrWebAuthnCeremony, err)
+	}
+
+	err = ws.credentialRepo.UpdateCredentialUsage(base64.RawURLEncoding.EncodeToString(credential.ID), credential.Authenticator.SignCount, credential.Authenticator.CloneWarning, time.Now())
+	if err != nil {
+		return nil, utils.GenerateError(ErrStoringCredential, err)
+	}
+
+	//Possibly Cloned Authenticator
+	if credential.Authenticator.CloneWarning {
+		return nil, utils.GenerateError(ErrWebAuthnCeremony, errors.New("authenticator sign count did not increase"))
+	}
+
+	user := waUser.user
+	if isLockedOut(user, time.Now()) {
+		return nil, utils.GenerateError(ErrAccountLocked, errors.New("passkey sign-in attempted while locked"))
+	}
+
+	if !user.Verified {
+		return nil, utils.GenerateError(ErrUserNotVerified, errors.New("passkey sign-in attempted while not verified"))
+	}
+
+	return user, nil
+}
+
+func (ws WebAuthnService) FindCredentialsByUserId(userID string) ([]*models.WebAuthnCredential, error) {
+	credentials, err := ws.credentialRepo.FindCredentialsByUserID(userID)
+	if err != nil {
+		return nil, utils.GenerateError(ErrFindingCredentials, err)
+	}
+	return credentials, nil
+}
+
+func (ws WebAuthnService) DeleteCredential(id string, userID string) error {
+	err := ws.credentialRepo.DeleteCredential(id, userID)
+	if err != nil {
+		if errors.Is(err, repos.ErrCredentialNotFound) || errors.Is(err, repos.ErrInvalidIDHex) {
+			return utils.GenerateError(ErrCredentialNotFound, err)
+		}
+		return utils.GenerateError(ErrStoringCredential, err)
+	}
+	return nil
+}
+
+func (ws WebAuthnService) loadUser(user *models.DBResponse) (*webAuthnUser, error) {
+	stored, err := ws.credentialRepo.FindCredentialsByUserID(user.ID.Hex())
+	if err != nil {
+		return nil, utils.GenerateError(ErrFindingCredentials, err)
+	}
+
+	credentials := make([]webauthn.Credential, len(stored))
+	for i, credential := range stored {
+		credentials[i] = credential.Credential
+	}
+
+	return &webAuthnUser{user, credentials}, nil
+}
+
+func (ws WebAuthnService) storeSession(key string, session *webauthn.SessionData) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return utils.GenerateError(ErrStoringToken, err)
+	}
+
+	if err := ws.tokenRepo.StoreWebAuthnSession(key, data, webAuthnSessionTTL); err != nil {
+		return utils.GenerateError(ErrStoringToken, err)
+	}
+
+	return nil
+}
+
+func (ws WebAuthnService) consumeSession(key string) (*webauthn.SessionData, error) {
+	data, err := ws.tokenRepo.ConsumeWebAuthnSession(key)
+	if err != nil {
+		if errors.Is(err, repos.ErrWebAuthnSessionNotFound) {
+			return nil, utils.GenerateError(ErrWebAuthnSessionExpired, err)
+		}
+		return nil, utils.GenerateError(ErrReadingTokenState, err)
+	}
+
+	session := &webauthn.SessionData{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, utils.GenerateError(ErrReadingTokenState, err)
+	}
+
+	return session, nil
+}
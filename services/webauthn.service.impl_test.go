@@ -0,0 +1,415 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/AmadoJunior/Gipitty/repos"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:3000"
+)
+
+// Fakes Override Only What the Ceremonies Use
+type fakeWebAuthnUserRepo struct {
+	repos.IUserRepo
+	user *models.DBResponse
+}
+
+func (fr *fakeWebAuthnUserRepo) FindUserByID(id string) (*models.DBResponse, error) {
+	if fr.user == nil || fr.user.ID.Hex() != id {
+		return nil, repos.ErrUserNotFound
+	}
+	return fr.user, nil
+}
+
+type fakeCredentialRepo struct {
+	repos.ICredentialRepo
+	credentials []*models.WebAuthnCredential
+}
+
+func (fr *fakeCredentialRepo) CreateCredential(credential *models.WebAuthnCredential) (string, error) {
+	credential.ID = primitive.NewObjectID()
+	fr.credentials = append(fr.credentials, credential)
+	return credential.ID.Hex(), nil
+}
+
+func (fr *fakeCredentialRepo) FindCredentialsByUserID(userID string) ([]*models.WebAuthnCredential, error) {
+	var found []*models.WebAuthnCredential
+	for _, credential := range fr.credentials {
+		if credential.UserID.Hex() == userID {
+			found = append(found, credential)
+		}
+	}
+	return found, nil
+}
+
+func (fr *fakeCredentialRepo) UpdateCredentialUsage(credentialID string, signCount uint32, cloneWarning bool, lastUsedAt time.Time) error {
+	for _, credential := range fr.credentials {
+		if credential.CredentialID == credentialID {
+			credential.Credential.Authenticator.SignCount = signCount
+			credential.Credential.Authenticator.CloneWarning = cloneWarning
+			credential.LastUsedAt = lastUsedAt
+			return nil
+		}
+	}
+	return repos.ErrCredentialNotFound
+}
+
+type fakeSessionTokenRepo struct {
+	repos.ITokenRepo
+	sessions map[string][]byte
+}
+
+func (fr *fakeSessionTokenRepo) StoreWebAuthnSession(key string, data []byte, ttl time.Duration) error {
+	fr.sessions[key] = data
+	return nil
+}
+
+func (fr *fakeSessionTokenRepo) ConsumeWebAuthnSession(key string) ([]byte, error) {
+	data, ok := fr.sessions[key]
+	if !ok {
+		return nil, repos.ErrWebAuthnSessionNotFound
+	}
+	delete(fr.sessions, key)
+	return data, nil
+}
+
+// A software authenticator holding a single P-256 passkey.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, credentialID: credentialID}
+}
+
+func (sa *softAuthenticator) clientData(t *testing.T, ceremony string, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (sa *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, sa.signCount)
+	return append(data, attested...)
+}
+
+// Answers navigator.credentials.create() with a "none" attestation.
+func (sa *softAuthenticator) create(t *testing.T, challenge string) []byte {
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: sa.key.X.FillBytes(make([]byte, 32)),
+		YCoord: sa.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attested := make([]byte, 16) //Zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(sa.credentialID)))
+	attested = append(attested, sa.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestationObject, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": sa.authData(0x45, attested), //UP, UV, AT
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sa.credentialJSON(t, map[string]string{
+		"clientDataJSON":    encodeURL(sa.clientData(t, "webauthn.create", challenge)),
+		"attestationObject": encodeURL(attestationObject),
+	})
+}
+
+// Answers navigator.credentials.get() for the given user handle.
+func (sa *softAuthenticator) get(t *testing.T, challenge string, userHandle []byte) []byte {
+	sa.signCount++
+	authData := sa.authData(0x05, nil) //UP, UV
+	clientData := sa.clientData(t, "webauthn.get", challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, sa.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return sa.credentialJSON(t, map[string]string{
+		"clientDataJSON":    encodeURL(clientData),
+		"authenticatorData": encodeURL(authData),
+		"signature":         encodeURL(signature),
+		"userHandle":        encodeURL(userHandle),
+	})
+}
+
+func (sa *softAuthenticator) credentialJSON(t *testing.T, response map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encodeURL(sa.credentialID),
+		"rawId":    encodeURL(sa.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func encodeURL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func postJSON(body []byte) *http.Request {
+	request := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+func newTestWebAuthnService(t *testing.T) (IWebAuthnService, *models.DBResponse, *fakeCredentialRepo) {
+	user := &models.DBResponse{ID: primitive.NewObjectID(), Name: "Jane", Email: "jane@example.com", Verified: true}
+	credentialRepo := &fakeCredentialRepo{}
+
+	service, err := NewWebAuthnService(
+		&fakeWebAuthnUserRepo{user: user},
+		credentialRepo,
+		&fakeSessionTokenRepo{sessions: map[string][]byte{}},
+		&config.Config{WebAuthnRPID: testRPID, WebAuthnRPDisplayName: "Gipitty", WebAuthnRPOrigins: []string{testOrigin}},
+		context.Background(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return service, user, credentialRepo
+}
+
+func registerPasskey(t *testing.T, service IWebAuthnService, user *models.DBResponse, authenticator *softAuthenticator) {
+	creation, err := service.BeginRegistration(user)
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+
+	body := authenticator.create(t, creation.Response.Challenge.String())
+	if _, err := service.FinishRegistration(user, "Laptop", postJSON(body)); err != nil {
+		t.Fatalf("FinishRegistration: %v", err)
+	}
+}
+
+func TestWebAuthnRegistration(t *testing.T) {
+	service, user, credentialRepo := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+
+	registerPasskey(t, service, user, authenticator)
+
+	if len(credentialRepo.credentials) != 1 {
+		t.Fatalf("stored %d credentials, want 1", len(credentialRepo.credentials))
+	}
+	stored := credentialRepo.credentials[0]
+	if stored.Name != "Laptop" || stored.UserID != user.ID {
+		t.Fatalf("stored credential = %+v", stored)
+	}
+	if !bytes.Equal(stored.Credential.ID, authenticator.credentialID) {
+		t.Fatal("stored credential id does not match the authenticator's")
+	}
+	if stored.CredentialID != encodeURL(authenticator.credentialID) {
+		t.Fatalf("credential id = %q, want the base64url raw id", stored.CredentialID)
+	}
+
+	//Registration Sessions Are Single Use
+	_, err := service.FinishRegistration(user, "", postJSON(authenticator.create(t, "replayed")))
+	if !errors.Is(err, ErrWebAuthnSessionExpired) {
+		t.Fatalf("replayed registration err = %v, want %v", err, ErrWebAuthnSessionExpired)
+	}
+}
+
+func TestWebAuthnRegistrationRejectsWrongChallenge(t *testing.T) {
+	service, user, credentialRepo := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+
+	if _, err := service.BeginRegistration(user); err != nil {
+		t.Fatal(err)
+	}
+
+	body := authenticator.create(t, encodeURL([]byte("not the issued challenge")))
+	if _, err := service.FinishRegistration(user, "", postJSON(body)); !errors.Is(err, ErrWebAuthnCeremony) {
+		t.Fatalf("err = %v, want %v", err, ErrWebAuthnCeremony)
+	}
+	if len(credentialRepo.credentials) != 0 {
+		t.Fatal("credential stored despite a failed ceremony")
+	}
+}
+
+func TestWebAuthnLogin(t *testing.T) {
+	service, user, credentialRepo := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+
+	assertion, sessionID, err := service.BeginLogin()
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+
+	body := authenticator.get(t, assertion.Response.Challenge.String(), user.ID[:])
+	signedIn, err := service.FinishLogin(sessionID, postJSON(body))
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+	if signedIn.ID != user.ID {
+		t.Fatalf("signed in as %s, want %s", signedIn.ID.Hex(), user.ID.Hex())
+	}
+
+	stored := credentialRepo.credentials[0]
+	if stored.Credential.Authenticator.SignCount != authenticator.signCount || stored.LastUsedAt.IsZero() {
+		t.Fatalf("credential usage not recorded: %+v", stored)
+	}
+}
+
+func TestWebAuthnLoginRejectsBadAssertions(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, authenticator *softAuthenticator, challenge string, user *models.DBResponse) []byte
+	}{
+		{"signature from another key", func(t *testing.T, authenticator *softAuthenticator, challenge string, user *models.DBResponse) []byte {
+			impostor := newSoftAuthenticator(t)
+			impostor.credentialID = authenticator.credentialID
+			return impostor.get(t, challenge, user.ID[:])
+		}},
+		{"wrong challenge", func(t *testing.T, authenticator *softAuthenticator, challenge string, user *models.DBResponse) []byte {
+			return authenticator.get(t, encodeURL([]byte("not the issued challenge")), user.ID[:])
+		}},
+		{"unknown user handle", func(t *testing.T, authenticator *softAuthenticator, challenge string, user *models.DBResponse) []byte {
+			other := primitive.NewObjectID()
+			return authenticator.get(t, challenge, other[:])
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, user, _ := newTestWebAuthnService(t)
+			authenticator := newSoftAuthenticator(t)
+			registerPasskey(t, service, user, authenticator)
+
+			assertion, sessionID, err := service.BeginLogin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := tt.tamper(t, authenticator, assertion.Response.Challenge.String(), user)
+			if _, err := service.FinishLogin(sessionID, postJSON(body)); !errors.Is(err, ErrWebAuthnCeremony) {
+				t.Fatalf("err = %v, want %v", err, ErrWebAuthnCeremony)
+			}
+		})
+	}
+}
+
+func TestWebAuthnLoginRejectsClonedAuthenticator(t *testing.T) {
+	service, user, _ := newTestWebAuthnService(t)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+
+	login := func() error {
+		assertion, sessionID, err := service.BeginLogin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = service.FinishLogin(sessionID, postJSON(authenticator.get(t, assertion.Response.Challenge.String(), user.ID[:])))
+		return err
+	}
+
+	if err := login(); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+
+	//A Copy of the Key Replays the Same Counter
+	authenticator.signCount--
+	if err := login(); !errors.Is(err, ErrWebAuthnCeremony) {
+		t.Fatalf("cloned login err = %v, want %v", err, ErrWebAuthnCeremony)
+	}
+}
+
+type fakeMFAChallengeRepo struct {
+	repos.ITokenRepo
+	challenges map[string]string
+}
+
+func (fr *fakeMFAChallengeRepo) CreateMFAChallenge(token string, userID string, ttl time.Duration) error {
+	fr.challenges[token] = userID
+	return nil
+}
+
+func TestWebAuthnLoginRequiresSecondFactor(t *testing.T) {
+	service, user, _ := newTestWebAuthnService(t)
+	user.TOTPEnabled = true
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user, authenticator)
+
+	assertion, sessionID, err := service.BeginLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedIn, err := service.FinishLogin(sessionID, postJSON(authenticator.get(t, assertion.Response.Challenge.String(), user.ID[:])))
+	if err != nil {
+		t.Fatalf("FinishLogin: %v", err)
+	}
+
+	//The Controller Hands the Passkey User to CompleteSignIn Like Any First Factor
+	tokenRepo := &fakeMFAChallengeRepo{challenges: map[string]string{}}
+	authService := &AuthService{TokenRepo: tokenRepo}
+	tokens, err := authService.CompleteSignIn(signedIn, &models.ClientInfo{}, &config.Config{MFAChallengeExpiresIn: time.Minute})
+	if err != nil {
+		t.Fatalf("CompleteSignIn: %v", err)
+	}
+	if tokens.MFAToken == "" || tokens.AccessToken != "" || tokens.RefreshToken != "" {
+		t.Fatalf("tokens = %+v, want only an mfa token", tokens)
+	}
+	if len(tokenRepo.challenges) != 1 {
+		t.Fatalf("stored %d mfa challenges, want 1", len(tokenRepo.challenges))
+	}
+	for _, userID := range tokenRepo.challenges {
+		if userID != user.ID.Hex() {
+			t.Fatalf("challenge for %s, want %s", userID, user.ID.Hex())
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/thanhpk/randstr"
+)
+
+const (
+	APIKeyPrefix       = "gpt_"
+	apiKeyDisplayChars = 8
+)
+
+// Returns a new key and the non-secret prefix shown to identify it.
+func GenerateAPIKey() (string, string) {
+	key := APIKeyPrefix + randstr.String(40)
+	return key, key[:len(APIKeyPrefix)+apiKeyDisplayChars]
+}
+
+// Keys carry enough entropy that a fast hash is sufficient for lookups.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
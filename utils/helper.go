@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"fmt"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson"
 )
 
@@ -13,3 +16,21 @@ func ToDoc(v interface{}) (doc *bson.D, err error) {
 	err = bson.Unmarshal(data, &doc)
 	return
 }
+
+// Formats a duration for emails, e.g. 10min, 1h or 1h30min.
+func HumanizeDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+
+	d = d.Round(time.Minute)
+	hours, minutes := int(d/time.Hour), int(d%time.Hour/time.Minute)
+
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dmin", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%dmin", hours, minutes)
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/thanhpk/randstr"
+)
+
+// Server secret mixed into token digests, set once at startup.
+var tokenPepper []byte
+
+// Keys token digests with pepper, so digests read from the database cannot be
+// checked offline against guessed tokens. Without one, digests are plain
+// SHA-256. Digests carry no version, so setting, changing or removing the
+// pepper invalidates every outstanding one-time token (verification codes,
+// reset, magic link, unlock, restore, email change and export links) and
+// users have to request new ones. It also invalidates every TOTP recovery
+// code, which cannot be reissued without signing in. Set it before the
+// first start.
+func UseTokenPepper(pepper string) {
+	tokenPepper = []byte(pepper)
+}
+
+// Returns a new one-time token to hand to the user and the digest to store.
+func NewOneTimeToken(length int) (string, string) {
+	token := randstr.String(length)
+	return token, HashOneTimeToken(token)
+}
+
+// One-time tokens carry enough entropy that a fast hash is sufficient. They
+// are only ever matched by querying for the digest, never compared in memory,
+// so match timing can at most reveal a digest prefix, not the token.
+func HashOneTimeToken(token string) string {
+	if len(tokenPepper) == 0 {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, tokenPepper)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
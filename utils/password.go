@@ -1,21 +1,162 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
 
-	if err != nil {
-		return "", fmt.Errorf("could not hash password %w", err)
+// A password hashing algorithm producing self-describing strings, carrying
+// the algorithm and its parameters.
+type PasswordHashAlgorithm interface {
+	Hash(password string) (string, error)
+	Verify(hashedPassword string, candidatePassword string) error
+	//Whether hashedPassword Uses Other Parameters Than This Algorithm
+	NeedsRehash(hashedPassword string) bool
+	//Rejects Parameters That Would Fail or Weaken Every Hash
+	Validate() error
+}
+
+// Verifies against any supported format, not only the configured one.
+func VerifyPassword(hashedPassword string, candidatePassword string) error {
+	switch {
+	case strings.HasPrefix(hashedPassword, "$argon2id$"):
+		return Argon2idHasher{}.Verify(hashedPassword, candidatePassword)
+	case strings.HasPrefix(hashedPassword, "$2"):
+		return BcryptHasher{}.Verify(hashedPassword, candidatePassword)
 	}
+	return ErrUnknownPasswordHash
+}
 
-	return string(hashedPassword), nil
+// Hashes with bcrypt at a fixed cost, kept for existing hashes.
+type BcryptHasher struct {
+	Cost int
 }
 
-func VerifyPassword(hashedPassword string, candidatePassword string) error {
+func (bh BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bh.Cost)
+	return string(hashedPassword), err
+}
+
+func (bh BcryptHasher) Verify(hashedPassword string, candidatePassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(candidatePassword))
 }
+
+func (bh BcryptHasher) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != bh.Cost
+}
+
+func (bh BcryptHasher) Validate() error {
+	//Lower Costs Are Silently Raised to the Default by bcrypt
+	if bh.Cost < bcrypt.MinCost || bh.Cost > bcrypt.MaxCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, bh.Cost)
+	}
+	return nil
+}
+
+const (
+	minArgon2SaltLength = 8
+	minArgon2KeyLength  = 16
+)
+
+// Hashes with Argon2id, encoded as $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func (ah Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, ah.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, ah.Iterations, ah.Memory, ah.Parallelism, ah.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, ah.Memory, ah.Iterations, ah.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (ah Argon2idHasher) Verify(hashedPassword string, candidatePassword string) error {
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	candidateKey := argon2.IDKey([]byte(candidatePassword), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, candidateKey) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+
+	return nil
+}
+
+func (ah Argon2idHasher) NeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	return err != nil || *params != ah
+}
+
+func (ah Argon2idHasher) Validate() error {
+	switch {
+	case ah.Iterations < 1:
+		return errors.New("argon2 iterations must be at least 1")
+	case ah.Parallelism < 1:
+		return errors.New("argon2 parallelism must be at least 1")
+	case ah.Memory < 8*uint32(ah.Parallelism):
+		return fmt.Errorf("argon2 memory must be at least %d KiB for parallelism %d", 8*uint32(ah.Parallelism), ah.Parallelism)
+	case ah.SaltLength < minArgon2SaltLength:
+		return fmt.Errorf("argon2 salt length must be at least %d bytes", minArgon2SaltLength)
+	case ah.KeyLength < minArgon2KeyLength:
+		return fmt.Errorf("argon2 key length must be at least %d bytes", minArgon2KeyLength)
+	}
+	return nil
+}
+
+func decodeArgon2id(hashedPassword string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrUnknownPasswordHash
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrUnknownPasswordHash
+	}
+	//Argon2 Panics on These
+	if params.Iterations < 1 || params.Parallelism < 1 {
+		return nil, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrUnknownPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
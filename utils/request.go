@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/AmadoJunior/Gipitty/models"
+	"github.com/gin-gonic/gin"
+)
+
+func ExtractAccessToken(ctx *gin.Context) string {
+	authorizationHeader := ctx.Request.Header.Get("Authorization")
+	fields := strings.Fields(authorizationHeader)
+
+	if len(fields) == 2 && fields[0] == "Bearer" {
+		return fields[1]
+	}
+
+	cookie, err := ctx.Cookie("access_token")
+	if err != nil {
+		return ""
+	}
+
+	return cookie
+}
+
+// Reads an API key from the X-API-Key header or a gpt_ prefixed bearer token.
+func ExtractAPIKey(ctx *gin.Context) string {
+	if key := ctx.Request.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	fields := strings.Fields(ctx.Request.Header.Get("Authorization"))
+	if len(fields) == 2 && fields[0] == "Bearer" && strings.HasPrefix(fields[1], APIKeyPrefix) {
+		return fields[1]
+	}
+
+	return ""
+}
+
+func ExtractClientInfo(ctx *gin.Context) *models.ClientInfo {
+	return &models.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
+}
@@ -1,67 +1,84 @@
 package utils
 
 import (
-	"encoding/base64"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/AmadoJunior/Gipitty/config"
+	"github.com/AmadoJunior/Gipitty/models"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/thanhpk/randstr"
 )
 
-func CreateToken(ttl time.Duration, payload interface{}, privateKey string) (string, error) {
-	decodedPrivateKey, err := base64.StdEncoding.DecodeString(privateKey)
+// Signs the claims with the ring's active key, naming it in the kid header.
+// Timestamps are set from ttl and a jti is generated when none is given.
+func CreateToken(ttl time.Duration, claims *models.TokenClaims, keys *config.KeyRing) (string, error) {
+	key, err := keys.SigningKey()
 	if err != nil {
-		return "", fmt.Errorf("could not decode key: %w", err)
-	}
-	key, err := jwt.ParseRSAPrivateKeyFromPEM(decodedPrivateKey)
-	if err != nil {
-		return "", fmt.Errorf("create: parse key: %w", err)
+		return "", fmt.Errorf("create: %w", err)
 	}
 
 	now := time.Now().UTC()
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.NotBefore = jwt.NewNumericDate(now)
+	if claims.ID == "" {
+		claims.ID = randstr.Hex(32)
+	}
 
-	claims := make(jwt.MapClaims)
-	claims["sub"] = payload
-	claims["exp"] = now.Add(ttl).Unix()
-	claims["iat"] = now.Unix()
-	claims["nbf"] = now.Unix()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	signed, err := token.SignedString(key.PrivateKey)
 
 	if err != nil {
 		return "", fmt.Errorf("create: sign token: %w", err)
 	}
 
-	return token, nil
+	return signed, nil
 }
 
-func ValidateToken(token string, publicKey string) (interface{}, error) {
-	decodedPublicKey, err := base64.StdEncoding.DecodeString(publicKey)
-	if err != nil {
-		return nil, fmt.Errorf("could not decode: %w", err)
-	}
-
-	key, err := jwt.ParseRSAPublicKeyFromPEM(decodedPublicKey)
+// Verifies the signature against the key named by the kid header, then the
+// issuer and audience, which must match exactly.
+func ValidateToken(token string, keys *config.KeyRing, issuer string, audience string) (*models.TokenClaims, error) {
+	claims := &models.TokenClaims{}
+	parsedToken, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok {
+			return nil, errors.New("missing key id")
+		}
 
-	if err != nil {
-		return "", fmt.Errorf("validate: parse key: %w", err)
-	}
+		key, ok := keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %s", kid)
+		}
 
-	parsedToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+		if t.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("unexpected method: %s", t.Header["alg"])
 		}
-		return key, nil
-	})
+		return key.PublicKey, nil
+	}, jwt.WithValidMethods(keys.Algorithms()))
 
 	if err != nil {
 		return nil, fmt.Errorf("validate: %w", err)
 	}
 
-	claims, ok := parsedToken.Claims.(jwt.MapClaims)
-	if !ok || !parsedToken.Valid {
+	if !parsedToken.Valid {
 		return nil, fmt.Errorf("validate: invalid token")
 	}
 
-	return claims["sub"], nil
+	if !claims.VerifyIssuer(issuer, true) {
+		return nil, fmt.Errorf("validate: unexpected issuer %q", claims.Issuer)
+	}
+
+	if !claims.VerifyAudience(audience, true) {
+		return nil, fmt.Errorf("validate: unexpected audience %v", claims.Audience)
+	}
+
+	if claims.Subject == "" || claims.ID == "" {
+		return nil, fmt.Errorf("validate: missing sub or jti")
+	}
+
+	return claims, nil
 }
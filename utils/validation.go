@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Decodes a JSON body into obj, rejecting fields obj does not declare, and
+// validates it with its binding tags.
+func BindStrictJSON(ctx *gin.Context, obj interface{}) error {
+	decoder := json.NewDecoder(ctx.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// Maps failed binding tags to a message per JSON field of obj, or returns
+// nil when err is not a validation error.
+func ValidationErrors(err error, obj interface{}) map[string]string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	objType := reflect.TypeOf(obj)
+	for objType.Kind() == reflect.Ptr {
+		objType = objType.Elem()
+	}
+
+	fields := map[string]string{}
+	for _, fieldError := range validationErrors {
+		name := fieldError.Field()
+		if field, ok := objType.FieldByName(fieldError.StructField()); ok {
+			if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" {
+				name = tag
+			}
+		}
+		fields[name] = validationMessage(fieldError)
+	}
+	return fields
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return "must be at least " + fieldError.Param() + " characters"
+	case "max":
+		return "must be at most " + fieldError.Param() + " characters"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldError.Param(), " ", ", ")
+	case "timezone":
+		return "must be an IANA time zone such as Europe/Berlin"
+	case "bcp47_language_tag":
+		return "must be a language tag such as en-US"
+	}
+	return "is invalid"
+}